@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+type credentialOutput struct {
+	Host     string `json:"host"`
+	Scheme   string `json:"scheme"`
+	Username string `json:"username,omitempty"`
+}
+
+func NewAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manages credentials used to authenticate against download hosts",
+	}
+
+	cmd.AddCommand(
+		newAuthSetCmd(),
+		newAuthListCmd(),
+		newAuthDeleteCmd(),
+	)
+
+	return cmd
+}
+
+func newAuthSetCmd() *cobra.Command {
+	var scheme, username, password, token string
+
+	cmd := &cobra.Command{
+		Use:   "set <host>",
+		Short: "Stores credentials for a host, replacing existing ones",
+		Long: "Stores credentials for a host such as \"example.com\" or \"example.com:8443\". " +
+			"Credentials for a bare host name apply to every port of that host.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret := password
+			if downloads.AuthScheme(scheme) == downloads.AuthBearer {
+				if password != "" {
+					return errors.New("use --token instead of --password for bearer authentication")
+				}
+				secret = token
+			} else if token != "" {
+				return fmt.Errorf("--token is only valid with --scheme %s", downloads.AuthBearer)
+			}
+
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			return queueManager.SetCredential(cmd.Context(), state.UpsertCredentialParams{
+				Host:     args[0],
+				Scheme:   scheme,
+				Username: username,
+				Secret:   secret,
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&scheme, "scheme", string(downloads.AuthBasic), "authentication scheme: basic, digest or bearer")
+	flags.StringVarP(&username, "user", "u", "", "user name for basic and digest authentication")
+	flags.StringVarP(&password, "password", "p", "", "password for basic and digest authentication")
+	flags.StringVar(&token, "token", "", "token for bearer authentication")
+
+	return cmd
+}
+
+func newAuthListCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists hosts with stored credentials, secrets are never printed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			credentials, err := queueManager.ListCredentials(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			output := make([]credentialOutput, 0, len(credentials))
+			for _, credential := range credentials {
+				output = append(output, credentialOutput{
+					Host:     credential.Host,
+					Scheme:   credential.Scheme,
+					Username: credential.Username,
+				})
+			}
+
+			if asJSON {
+				return writeJSON(cmd.OutOrStdout(), output)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "HOST\tSCHEME\tUSER")
+			for _, credential := range output {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", credential.Host, credential.Scheme, credential.Username)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print credentials as JSON")
+
+	return cmd
+}
+
+func newAuthDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <host>...",
+		Aliases: []string{"rm"},
+		Short:   "Deletes stored credentials",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			for _, host := range args {
+				if err := queueManager.DeleteCredential(cmd.Context(), host); err != nil {
+					return fmt.Errorf("could not delete credentials for %q: %w", host, err)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/download-manager.git/internal/api"
+	"github.com/computer-technology-team/download-manager.git/internal/aria2"
+	"github.com/computer-technology-team/download-manager.git/internal/daemon"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func NewDaemonCmd() *cobra.Command {
+	var httpAddr, httpToken string
+	var engine engineFlags
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Runs the download engine headless and serves it over a local control socket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slog.Info("starting download manager daemon")
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			engineOptions, err := engine.options()
+			if err != nil {
+				return err
+			}
+
+			socketPath, err := daemon.SocketPath()
+			if err != nil {
+				return err
+			}
+
+			listener, err := daemon.Listen(socketPath)
+			if err != nil {
+				slog.Error("failed to listen on daemon socket", "path", socketPath, "error", err)
+				return err
+			}
+			defer os.Remove(socketPath)
+
+			db, err := state.SetupDatabase(ctx)
+			if err != nil {
+				slog.Error("failed to setup database", "error", err)
+				return err
+			}
+			defer db.Close()
+
+			queueManager, err := queues.New(db, engineOptions...)
+			if err != nil {
+				return err
+			}
+
+			broadcaster := events.NewBroadcaster()
+
+			go queues.Listen(queueManager, ctx)
+			go queues.Schedule(queueManager, ctx)
+			go broadcaster.Run(ctx, events.GetUIEventChannel())
+
+			cmd.Printf("download manager daemon listening on %s\n", socketPath)
+
+			if httpAddr != "" {
+				if err := serveHTTPAPI(ctx, cmd, httpAddr, httpToken, queueManager, broadcaster); err != nil {
+					return err
+				}
+			}
+
+			return daemon.NewServer(queueManager, broadcaster).Serve(ctx, listener)
+		},
+	}
+
+	cmd.Flags().StringVar(&httpAddr, "http-addr", "",
+		"loopback address to serve the HTTP API on, for example 127.0.0.1:6800 (disabled when empty)")
+	cmd.Flags().StringVar(&httpToken, "http-token", "",
+		"bearer token required by the HTTP API, defaults to a token generated in the app data directory")
+	engine.register(cmd)
+
+	return cmd
+}
+
+func serveHTTPAPI(ctx context.Context, cmd *cobra.Command, addr, token string,
+	queueManager queues.QueueManager, broadcaster *events.Broadcaster) error {
+	if token == "" {
+		var err error
+		if token, err = api.LoadOrCreateToken(); err != nil {
+			return err
+		}
+	}
+
+	listener, err := api.Listen(addr)
+	if err != nil {
+		slog.Error("failed to listen for http api", "addr", addr, "error", err)
+		return err
+	}
+
+	aria2Handler := aria2.NewHandler(queueManager, token)
+	go aria2Handler.Run(ctx, broadcaster)
+
+	server := api.NewServer(queueManager, broadcaster, token,
+		api.WithSelfAuthenticatedHandler("/jsonrpc", aria2Handler))
+
+	go func() {
+		if err := server.Serve(ctx, listener); err != nil {
+			slog.Error("http api failed", "error", err)
+		}
+	}()
+
+	cmd.Printf("http api listening on http://%s\n", listener.Addr())
+	return nil
+}
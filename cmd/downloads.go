@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/download-manager.git/internal/api"
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+)
+
+func NewAddCmd() *cobra.Command {
+	var queue, fileName, checksum, userAgent string
+	var connections int64
+	var headerLines []string
+	var replaceCredentials bool
+
+	cmd := &cobra.Command{
+		Use:   "add <url>",
+		Short: "Adds a download to a queue",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			headers, err := downloads.ParseHeaders(headerLines)
+			if err != nil {
+				return err
+			}
+			if userAgent != "" {
+				headers["User-Agent"] = userAgent
+			}
+
+			queueID, err := resolveQueueID(cmd.Context(), queueManager, queue)
+			if err != nil {
+				return err
+			}
+
+			id, err := queueManager.CreateDownload(cmd.Context(), queues.CreateDownloadParams{
+				URL:         args[0],
+				FileName:    fileName,
+				QueueID:     queueID,
+				Checksum:    checksum,
+				Connections: connections,
+				Headers:     headers,
+
+				ReplaceCredentials: replaceCredentials,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), id)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&queue, "queue", "q", "", "id or name of the queue to add the download to")
+	cmd.Flags().StringVarP(&fileName, "name", "n", "", "file name to save as, defaults to the name suggested by the server or the url")
+	cmd.Flags().StringVar(&checksum, "checksum", "",
+		"expected checksum verified after the download completes, as <md5|sha1|sha256|sha512>:<hex digest>")
+	cmd.Flags().Int64Var(&connections, "connections", 0,
+		"number of parallel connections to use, defaults to the setting of the queue")
+	cmd.Flags().StringArrayVarP(&headerLines, "header", "H", nil,
+		`request header sent with every request of the download, as "Name: Value", can be repeated`)
+	cmd.Flags().StringVar(&userAgent, "user-agent", "", "User-Agent header sent with every request of the download")
+	cmd.Flags().BoolVar(&replaceCredentials, "replace-credentials", false,
+		"store credentials in the url even if the host already has credentials of another scheme")
+	_ = cmd.MarkFlagRequired("queue")
+
+	return cmd
+}
+
+func NewListCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists all downloads",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			rows, err := queueManager.ListDownloadsWithQueueName(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			output := make([]api.Download, 0, len(rows))
+			for _, row := range rows {
+				output = append(output, api.NewDownload(row))
+			}
+
+			if asJSON {
+				return writeJSON(cmd.OutOrStdout(), output)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tQUEUE\tSTATE\tRETRIES\tSIZE\tADDED\tSAVE PATH\tURL")
+			for _, download := range output {
+				size := fmt.Sprintf("%d B", download.BytesDownloaded)
+				if download.TotalSize != nil {
+					size = fmt.Sprintf("%d/%d B", download.BytesDownloaded, *download.TotalSize)
+				}
+				added := "-"
+				if download.CreatedAt != nil {
+					added = download.CreatedAt.Local().Format(time.DateTime)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", download.ID, download.Queue,
+					download.State, download.Retries, size, added, download.SavePath, download.URL)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print downloads as JSON")
+
+	return cmd
+}
+
+func NewPauseCmd() *cobra.Command {
+	return newDownloadActionCmd("pause", "Pauses a download", queues.QueueManager.PauseDownload)
+}
+
+func NewResumeCmd() *cobra.Command {
+	return newDownloadActionCmd("resume", "Resumes a paused download", queues.QueueManager.ResumeDownload)
+}
+
+func NewRetryCmd() *cobra.Command {
+	return newDownloadActionCmd("retry", "Retries a failed download", queues.QueueManager.RetryDownload)
+}
+
+func NewRemoveCmd() *cobra.Command {
+	cmd := newDownloadActionCmd("rm", "Removes a download", queues.QueueManager.DeleteDownload)
+	cmd.Aliases = []string{"remove"}
+	return cmd
+}
+
+func newDownloadActionCmd(use, short string,
+	action func(queues.QueueManager, context.Context, int64) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <id>...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int64, 0, len(args))
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+			}
+
+			queueManager, closeManager, err := openQueueManager(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeManager()
+
+			for _, id := range ids {
+				if err := action(queueManager, cmd.Context(), id); err != nil {
+					return fmt.Errorf("could not %s download %d: %w", use, id, err)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func openQueueManager(ctx context.Context) (queues.QueueManager, func(), error) {
+	if client, err := dialDaemon(); err == nil {
+		slog.Info("using running download manager daemon")
+		return client, func() { client.Close() }, nil
+	}
+
+	db, err := state.SetupDatabase(ctx)
+	if err != nil {
+		slog.Error("failed to setup database", "error", err)
+		return nil, nil, err
+	}
+
+	drainCtx, stopDrain := context.WithCancel(ctx)
+	go drainEvents(drainCtx, events.GetUIEventChannel())
+
+	queueManager, err := queues.New(db, queues.WithoutEngine())
+	if err != nil {
+		stopDrain()
+		db.Close()
+		return nil, nil, err
+	}
+
+	return queueManager, func() {
+		stopDrain()
+		db.Close()
+	}, nil
+}
+
+func drainEvents(ctx context.Context, eventChannel <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-eventChannel:
+		}
+	}
+}
+
+func parseID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", value, err)
+	}
+	return id, nil
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
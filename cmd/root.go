@@ -5,33 +5,60 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/computer-technology-team/download-manager.git/internal/daemon"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
 	"github.com/computer-technology-team/download-manager.git/internal/queues"
 	"github.com/computer-technology-team/download-manager.git/internal/state"
 	"github.com/computer-technology-team/download-manager.git/internal/ui"
 )
 
 func NewRootCmd() *cobra.Command {
+	var engine engineFlags
+
 	cmd := &cobra.Command{
-		Use:   "download-manager",
-		Short: "Starts download manager TUI in default state",
+		Use:          "download-manager",
+		Short:        "Starts download manager TUI in default state",
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			slog.Info("starting download manager tui program")
 
 			ctx := cmd.Context()
 
-			db, err := state.SetupDatabase(ctx)
-			if err != nil {
-				slog.Error("failed to setup database", "error", err)
-				return err
-			}
+			var queueManager queues.QueueManager
 
-			queueManager, err := queues.New(db)
+			if client, err := dialDaemon(); err == nil {
+				slog.Info("attaching to running download manager daemon")
+				defer client.Close()
 
-			if err != nil {
-				return err
-			}
+				go func() {
+					if err := client.RelayEvents(ctx, events.GetUIEventChannel()); err != nil {
+						slog.Error("lost connection to daemon event stream", "error", err)
+					}
+				}()
+
+				queueManager = client
+			} else {
+				engineOptions, err := engine.options()
+				if err != nil {
+					return err
+				}
+
+				db, err := state.SetupDatabase(ctx)
+				if err != nil {
+					slog.Error("failed to setup database", "error", err)
+					return err
+				}
+
+				manager, err := queues.New(db, engineOptions...)
+				if err != nil {
+					return err
+				}
 
-			go queues.Listen(queueManager, ctx)
+				go queues.Listen(manager, ctx)
+				go queues.Schedule(manager, ctx)
+
+				queueManager = manager
+			}
 
 			teaProgram, err := ui.NewDownloadManagerProgram(ctx, queueManager)
 			if err != nil {
@@ -42,5 +69,29 @@ func NewRootCmd() *cobra.Command {
 			return err
 		},
 	}
+
+	engine.register(cmd)
+
+	cmd.AddCommand(
+		NewDaemonCmd(),
+		NewAddCmd(),
+		NewListCmd(),
+		NewPauseCmd(),
+		NewResumeCmd(),
+		NewRetryCmd(),
+		NewRemoveCmd(),
+		NewQueueCmd(),
+		NewAuthCmd(),
+	)
+
 	return cmd
 }
+
+func dialDaemon() (*daemon.Client, error) {
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return daemon.Dial(socketPath)
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	maxRequestBody    = 1 << 20
+	defaultRetryLimit = 3
+)
+
+var ErrNotLoopback = errors.New("http api may only listen on a loopback address")
+
+type Server struct {
+	queueManager queues.QueueManager
+	broadcaster  *events.Broadcaster
+	token        string
+	mux          *http.ServeMux
+	selfAuthMux  *http.ServeMux
+}
+
+type Option func(*Server)
+
+func WithSelfAuthenticatedHandler(pattern string, handler http.Handler) Option {
+	return func(s *Server) {
+		s.selfAuthMux.Handle(pattern, handler)
+	}
+}
+
+func NewServer(queueManager queues.QueueManager, broadcaster *events.Broadcaster, token string, opts ...Option) *Server {
+	s := &Server{
+		queueManager: queueManager,
+		broadcaster:  broadcaster,
+		token:        token,
+		mux:          http.NewServeMux(),
+		selfAuthMux:  http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /api/downloads", s.listDownloads)
+	s.mux.HandleFunc("POST /api/downloads", s.createDownload)
+	s.mux.HandleFunc("DELETE /api/downloads/{id}", s.downloadAction(queues.QueueManager.DeleteDownload))
+	s.mux.HandleFunc("POST /api/downloads/{id}/pause", s.downloadAction(queues.QueueManager.PauseDownload))
+	s.mux.HandleFunc("POST /api/downloads/{id}/resume", s.downloadAction(queues.QueueManager.ResumeDownload))
+	s.mux.HandleFunc("POST /api/downloads/{id}/retry", s.downloadAction(queues.QueueManager.RetryDownload))
+
+	s.mux.HandleFunc("GET /api/queues", s.listQueues)
+	s.mux.HandleFunc("POST /api/queues", s.createQueue)
+	s.mux.HandleFunc("PUT /api/queues/{id}", s.updateQueue)
+	s.mux.HandleFunc("DELETE /api/queues/{id}", s.deleteQueue)
+
+	s.mux.HandleFunc("GET /api/events", s.streamEvents)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler, pattern := s.selfAuthMux.Handler(r); pattern != "" {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="download-manager"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func Listen(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http api address %q: %w", addr, err)
+	}
+
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("%w: %s", ErrNotLoopback, addr)
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %w", addr, err)
+	}
+
+	return listener, nil
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("could not shutdown http api gracefully", "error", err)
+		}
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http api stopped: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) listDownloads(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.queueManager.ListDownloadsWithQueueName(r.Context())
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	output := make([]Download, 0, len(rows))
+	for _, row := range rows {
+		output = append(output, NewDownload(row))
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) createDownload(w http.ResponseWriter, r *http.Request) {
+	var req queues.CreateDownloadParams
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.URL == "" {
+		writeError(w, http.StatusBadRequest, errors.New("url is required"))
+		return
+	}
+
+	id, err := s.queueManager.CreateDownload(r.Context(), req)
+	if err != nil && id == 0 {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if err != nil {
+		slog.Error("download created but could not be started", "downloadID", id, "error", err)
+	}
+
+	writeJSON(w, http.StatusCreated, createDownloadResponse{ID: id})
+}
+
+func (s *Server) downloadAction(action func(queues.QueueManager, context.Context, int64) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := action(s.queueManager, r.Context(), id); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) listQueues(w http.ResponseWriter, r *http.Request) {
+	queueList, err := s.queueManager.ListQueue(r.Context())
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	output := make([]Queue, 0, len(queueList))
+	for _, queue := range queueList {
+		output = append(output, NewQueue(queue))
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) createQueue(w http.ResponseWriter, r *http.Request) {
+	req := queueRequest{MaxConcurrent: 1, RetryLimit: defaultRetryLimit, Connections: downloads.DefaultConnections}
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.queueManager.CreateQueue(r.Context(), req.createParams()); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) updateQueue(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req queueRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.queueManager.EditQueue(r.Context(), req.updateParams(id)); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteQueue(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.queueManager.DeleteQueue(r.Context(), id); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, queues.ErrEmptyFileName), errors.Is(err, queues.ErrInvalidChecksum),
+		errors.Is(err, queues.ErrInvalidConnections), errors.Is(err, queues.ErrInvalidRetryLimit), errors.Is(err, downloads.ErrInvalidProxy),
+		errors.Is(err, downloads.ErrInvalidHeader), errors.Is(err, downloads.ErrInvalidConflictPolicy):
+		return http.StatusBadRequest
+	case errors.Is(err, downloads.ErrFileExists), errors.Is(err, queues.ErrSavePathInUse), errors.Is(err, queues.ErrCredentialConflict),
+		errors.Is(err, queues.ErrDownloadInProgress):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("could not write http api response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/computer-technology-team/download-manager.git/datadir"
+)
+
+const (
+	tokenFileName = "api-token"
+	tokenBytes    = 32
+)
+
+func LoadOrCreateToken() (string, error) {
+	appDataDir, err := datadir.GetAppDataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get app data directory: %w", err)
+	}
+
+	tokenPath := filepath.Join(appDataDir, tokenFileName)
+
+	data, err := os.ReadFile(tokenPath)
+	if err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("could not read api token: %w", err)
+	}
+
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("could not generate api token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("could not save api token: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+type Download struct {
+	ID       int64  `json:"id"`
+	URL      string `json:"url"`
+	SavePath string `json:"save_path"`
+	State    string `json:"state"`
+	Retries  int64  `json:"retries"`
+	QueueID  int64  `json:"queue_id"`
+	Queue    string `json:"queue"`
+	Checksum string `json:"checksum,omitempty"`
+
+	Connections *int64 `json:"connections,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	TotalSize       *int64     `json:"total_size,omitempty"`
+	BytesDownloaded int64      `json:"bytes_downloaded"`
+	ContentType     string     `json:"content_type,omitempty"`
+}
+
+func NewDownload(row state.ListDownloadsWithQueueNameRow) Download {
+	output := Download{
+		ID:       row.ID,
+		URL:      row.Url,
+		SavePath: row.SavePath,
+		State:    row.State,
+		Retries:  row.Retries,
+		QueueID:  row.QueueID,
+		Queue:    row.QueueName,
+		Checksum: row.Checksum.String,
+
+		LastError: row.LastError.String,
+
+		CreatedAt:       optionalTime(row.CreatedAt),
+		StartedAt:       optionalTime(row.StartedAt),
+		CompletedAt:     optionalTime(row.CompletedAt),
+		BytesDownloaded: row.BytesDownloaded,
+		ContentType:     row.ContentType.String,
+	}
+	if row.Connections.Valid {
+		output.Connections = &row.Connections.Int64
+	}
+	if row.TotalSize.Valid {
+		output.TotalSize = &row.TotalSize.Int64
+	}
+	return output
+}
+
+func optionalTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+type Queue struct {
+	ID             int64          `json:"id"`
+	Name           string         `json:"name"`
+	Directory      string         `json:"directory"`
+	MaxBandwidth   *int64         `json:"max_bandwidth"`
+	MaxConcurrent  int64          `json:"max_concurrent"`
+	RetryLimit     int64          `json:"retry_limit"`
+	Connections    int64          `json:"connections"`
+	Proxy          string         `json:"proxy,omitempty"`
+	NoProxy        string         `json:"no_proxy,omitempty"`
+	CookieFile     string         `json:"cookie_file,omitempty"`
+	TLSInsecure    bool           `json:"tls_insecure"`
+	ConflictPolicy string         `json:"conflict_policy"`
+	Schedule       state.Schedule `json:"schedule"`
+}
+
+func NewQueue(queue state.Queue) Queue {
+	output := Queue{
+		ID:             queue.ID,
+		Name:           queue.Name,
+		Directory:      queue.Directory,
+		MaxConcurrent:  queue.MaxConcurrent,
+		RetryLimit:     queue.RetryLimit,
+		Connections:    queue.Connections,
+		Proxy:          downloads.ProxyConfig{URL: queue.Proxy.String}.Redacted(),
+		NoProxy:        queue.NoProxy.String,
+		CookieFile:     queue.CookieFile.String,
+		TLSInsecure:    queue.TlsInsecure,
+		ConflictPolicy: queue.ConflictPolicy,
+	}
+	if queue.MaxBandwidth.Valid {
+		output.MaxBandwidth = &queue.MaxBandwidth.Int64
+	}
+	if queue.ScheduleMode {
+		output.Schedule = queue.Schedule
+	}
+	return output
+}
+
+type createDownloadResponse struct {
+	ID int64 `json:"id"`
+}
+
+type queueRequest struct {
+	Name           string         `json:"name"`
+	Directory      string         `json:"directory"`
+	MaxBandwidth   *int64         `json:"max_bandwidth"`
+	MaxConcurrent  int64          `json:"max_concurrent"`
+	RetryLimit     int64          `json:"retry_limit"`
+	Connections    int64          `json:"connections"`
+	Proxy          string         `json:"proxy"`
+	NoProxy        string         `json:"no_proxy"`
+	CookieFile     string         `json:"cookie_file"`
+	TLSInsecure    bool           `json:"tls_insecure"`
+	ConflictPolicy string         `json:"conflict_policy"`
+	Schedule       state.Schedule `json:"schedule"`
+}
+
+func (r queueRequest) validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Directory == "" {
+		return errors.New("directory is required")
+	}
+	if r.MaxBandwidth != nil && *r.MaxBandwidth <= 0 {
+		return errors.New("max_bandwidth must be positive, use null for unlimited")
+	}
+	if r.MaxConcurrent < 1 {
+		return errors.New("max_concurrent must be at least 1")
+	}
+	if err := queues.ValidateRetryLimit(r.RetryLimit); err != nil {
+		return err
+	}
+	if r.Connections < 0 || r.Connections > downloads.MaxConnections {
+		return fmt.Errorf("connections must be between 1 and %d, use 0 for the default", downloads.MaxConnections)
+	}
+	if err := (downloads.ProxyConfig{URL: r.Proxy, NoProxy: r.NoProxy}).Validate(); err != nil {
+		return err
+	}
+	if err := downloads.ValidateCookieFile(r.CookieFile); err != nil {
+		return err
+	}
+	if _, err := downloads.ParseConflictPolicy(r.ConflictPolicy); err != nil {
+		return err
+	}
+	if r.Schedule.Valid {
+		return r.Schedule.Validate()
+	}
+	return nil
+}
+
+func (r queueRequest) maxBandwidth() sql.NullInt64 {
+	if r.MaxBandwidth == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *r.MaxBandwidth, Valid: true}
+}
+
+func (r queueRequest) createParams() state.CreateQueueParams {
+	return state.CreateQueueParams{
+		Name:           r.Name,
+		Directory:      r.Directory,
+		MaxBandwidth:   r.maxBandwidth(),
+		Schedule:       r.Schedule,
+		RetryLimit:     r.RetryLimit,
+		MaxConcurrent:  r.MaxConcurrent,
+		ScheduleMode:   r.Schedule.Valid,
+		Connections:    r.Connections,
+		Proxy:          sql.NullString{String: r.Proxy, Valid: r.Proxy != ""},
+		NoProxy:        sql.NullString{String: r.NoProxy, Valid: r.NoProxy != ""},
+		CookieFile:     sql.NullString{String: r.CookieFile, Valid: r.CookieFile != ""},
+		TlsInsecure:    r.TLSInsecure,
+		ConflictPolicy: r.ConflictPolicy,
+	}
+}
+
+func (r queueRequest) updateParams(id int64) state.UpdateQueueParams {
+	return state.UpdateQueueParams{
+		Name:           r.Name,
+		MaxBandwidth:   r.maxBandwidth(),
+		Schedule:       r.Schedule,
+		RetryLimit:     r.RetryLimit,
+		MaxConcurrent:  r.MaxConcurrent,
+		ScheduleMode:   r.Schedule.Valid,
+		Directory:      r.Directory,
+		Connections:    r.Connections,
+		Proxy:          sql.NullString{String: r.Proxy, Valid: r.Proxy != ""},
+		NoProxy:        sql.NullString{String: r.NoProxy, Valid: r.NoProxy != ""},
+		CookieFile:     sql.NullString{String: r.CookieFile, Valid: r.CookieFile != ""},
+		TlsInsecure:    r.TLSInsecure,
+		ConflictPolicy: r.ConflictPolicy,
+		ID:             id,
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type progressPayload struct {
+	ID       int64   `json:"id"`
+	URL      string  `json:"url"`
+	Progress float64 `json:"progress"`
+	Speed    float64 `json:"speed"`
+	State    string  `json:"state"`
+}
+
+type stateChangedPayload struct {
+	ID    int64  `json:"id"`
+	State string `json:"state"`
+}
+
+type failedPayload struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"`
+	Error     string `json:"error"`
+	Kind      string `json:"kind,omitempty"`
+	Permanent bool   `json:"permanent"`
+}
+
+type deletedPayload struct {
+	ID int64 `json:"id"`
+}
+
+func eventPayload(event events.Event) interface{} {
+	switch payload := event.Payload.(type) {
+	case downloads.DownloadStatus:
+		return progressPayload{
+			ID:       payload.ID,
+			URL:      payload.URL,
+			Progress: payload.ProgressPercentage,
+			Speed:    payload.Speed,
+			State:    string(payload.State),
+		}
+	case events.DownloadFailedEvent:
+		failed := failedPayload{ID: payload.ID, URL: payload.URL, Kind: payload.Kind, Permanent: payload.Permanent}
+		if payload.Error != nil {
+			failed.Error = payload.Error.Error()
+		}
+		return failed
+	case state.SetDownloadStateParams:
+		return stateChangedPayload{ID: payload.ID, State: payload.State}
+	case state.ListDownloadsWithQueueNameRow:
+		return NewDownload(payload)
+	case state.Queue:
+		return NewQueue(payload)
+	case int64:
+		return deletedPayload{ID: payload}
+	default:
+		return payload
+	}
+}
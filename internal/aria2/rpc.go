@@ -0,0 +1,448 @@
+package aria2
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const (
+	version        = "1.37.0"
+	tokenPrefix    = "token:"
+	maxRequestBody = 1 << 20
+
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeFailure        = 1
+)
+
+var (
+	errUnauthorized = errors.New("Unauthorized")
+	errNoQueue      = errors.New("no queue to add the download to")
+)
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type addURIOptions struct {
+	Dir      string `json:"dir"`
+	Out      string `json:"out"`
+	Checksum string `json:"checksum"`
+	Split    string `json:"split"`
+}
+
+type Handler struct {
+	queueManager queues.QueueManager
+	token        string
+	cache        *statusCache
+}
+
+func NewHandler(queueManager queues.QueueManager, token string) *Handler {
+	return &Handler{
+		queueManager: queueManager,
+		token:        token,
+		cache:        newStatusCache(),
+	}
+}
+
+func (h *Handler) Run(ctx context.Context, broadcaster *events.Broadcaster) {
+	subscription, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	h.cache.run(ctx, subscription)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		allowHeaders := r.Header.Get("Access-Control-Request-Headers")
+		if allowHeaders == "" {
+			allowHeaders = "Content-Type"
+		}
+		w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		w.Header().Set("Access-Control-Max-Age", "1728000")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&body); err != nil {
+		writeResponse(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeParseError, Message: "Parse error."}})
+		return
+	}
+
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		var batch []rpcRequest
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeResponse(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: codeInvalidRequest, Message: "Invalid Request."}})
+			return
+		}
+
+		responses := make([]rpcResponse, 0, len(batch))
+		for _, req := range batch {
+			responses = append(responses, h.handle(r.Context(), req))
+		}
+		writeResponse(w, responses)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeResponse(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeInvalidRequest, Message: "Invalid Request."}})
+		return
+	}
+
+	writeResponse(w, h.handle(r.Context(), req))
+}
+
+func (h *Handler) handle(ctx context.Context, req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+
+	result, err := h.call(ctx, req.Method, req.Params)
+	if err != nil {
+		code := codeFailure
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			code = rpcErr.Code
+		}
+		resp.Error = &rpcError{Code: code, Message: err.Error()}
+		return resp
+	}
+
+	resp.Result = result
+	return resp
+}
+
+func (h *Handler) call(ctx context.Context, method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "system.multicall":
+		return h.multicall(ctx, params)
+	case "system.listMethods":
+		return methodNames, nil
+	}
+
+	params, err := h.authenticate(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "aria2.addUri":
+		return h.addURI(ctx, params)
+	case "aria2.tellStatus":
+		return h.tellStatus(ctx, params)
+	case "aria2.tellActive":
+		return h.tellByStatus(ctx, params, 0, statusActive)
+	case "aria2.tellWaiting":
+		return h.tellByStatus(ctx, params, 2, statusWaiting, statusPaused)
+	case "aria2.tellStopped":
+		return h.tellByStatus(ctx, params, 2, statusError, statusComplete)
+	case "aria2.pause", "aria2.forcePause":
+		return h.withGID(ctx, params, h.queueManager.PauseDownload)
+	case "aria2.unpause":
+		return h.withGID(ctx, params, h.unpause)
+	case "aria2.remove", "aria2.forceRemove":
+		return h.withGID(ctx, params, h.queueManager.DeleteDownload)
+	case "aria2.getGlobalStat":
+		return h.globalStat(ctx)
+	case "aria2.getVersion":
+		return map[string]interface{}{"version": version, "enabledFeatures": []string{}}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("No such method: %s", method)}
+	}
+}
+
+var methodNames = []string{
+	"aria2.addUri", "aria2.tellStatus", "aria2.tellActive", "aria2.tellWaiting", "aria2.tellStopped",
+	"aria2.pause", "aria2.forcePause", "aria2.unpause", "aria2.remove", "aria2.forceRemove",
+	"aria2.getGlobalStat", "aria2.getVersion", "system.multicall", "system.listMethods",
+}
+
+func (h *Handler) authenticate(params []json.RawMessage) ([]json.RawMessage, error) {
+	if len(params) > 0 {
+		var secret string
+		if err := json.Unmarshal(params[0], &secret); err == nil {
+			if token, ok := strings.CutPrefix(secret, tokenPrefix); ok {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1 {
+					return params[1:], nil
+				}
+				return nil, errUnauthorized
+			}
+		}
+	}
+
+	if h.token == "" {
+		return params, nil
+	}
+	return nil, errUnauthorized
+}
+
+func (h *Handler) multicall(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var calls []struct {
+		MethodName string            `json:"methodName"`
+		Params     []json.RawMessage `json:"params"`
+	}
+	if len(params) == 0 || json.Unmarshal(params[0], &calls) != nil {
+		return nil, errors.New("system.multicall expects an array of calls")
+	}
+
+	results := make([]interface{}, 0, len(calls))
+	for _, call := range calls {
+		result, err := h.call(ctx, call.MethodName, call.Params)
+		if err != nil {
+			results = append(results, rpcError{Code: codeFailure, Message: err.Error()})
+			continue
+		}
+		results = append(results, []interface{}{result})
+	}
+	return results, nil
+}
+
+func (h *Handler) addURI(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var uris []string
+	if len(params) == 0 || json.Unmarshal(params[0], &uris) != nil || len(uris) == 0 {
+		return nil, errors.New("aria2.addUri expects a non-empty array of URIs")
+	}
+
+	var options addURIOptions
+	if len(params) > 1 {
+		if err := json.Unmarshal(params[1], &options); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+
+	var connections int64
+	if options.Split != "" {
+		var err error
+		if connections, err = strconv.ParseInt(options.Split, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid split option %q", options.Split)
+		}
+	}
+
+	queueID, err := h.queueFor(ctx, options.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := h.queueManager.CreateDownload(ctx, queues.CreateDownloadParams{
+		URL:         uris[0],
+		FileName:    options.Out,
+		QueueID:     queueID,
+		Checksum:    options.Checksum,
+		Connections: connections,
+	})
+	if err != nil && id == 0 {
+		return nil, err
+	}
+	if err != nil {
+		slog.Error("download created but could not be started", "downloadID", id, "error", err)
+	}
+
+	return formatGID(id), nil
+}
+
+func (h *Handler) queueFor(ctx context.Context, dir string) (int64, error) {
+	queueList, err := h.queueManager.ListQueue(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(queueList) == 0 {
+		return 0, errNoQueue
+	}
+
+	if dir != "" {
+		for _, queue := range queueList {
+			if filepath.Clean(queue.Directory) == filepath.Clean(dir) {
+				return queue.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("no queue saves to %s", dir)
+	}
+
+	return queueList[0].ID, nil
+}
+
+func (h *Handler) tellStatus(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	id, err := gidParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := h.findDownload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.cache.describe(download, keysParam(params, 1)), nil
+}
+
+func (h *Handler) tellByStatus(ctx context.Context, params []json.RawMessage, keysIndex int, statuses ...string) (interface{}, error) {
+	offset, num := 0, -1
+	if keysIndex == 2 && len(params) >= 2 {
+		if json.Unmarshal(params[0], &offset) != nil || json.Unmarshal(params[1], &num) != nil {
+			return nil, errors.New("offset and num must be integers")
+		}
+	}
+
+	rows, err := h.queueManager.ListDownloadsWithQueueName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := keysParam(params, keysIndex)
+	result := make([]map[string]interface{}, 0)
+	for _, row := range rows {
+		status := aria2Status(row.State)
+		for _, wanted := range statuses {
+			if status == wanted {
+				result = append(result, h.cache.describe(row, keys))
+				break
+			}
+		}
+	}
+
+	if offset < 0 {
+		offset = max(len(result)+offset, 0)
+	}
+	if offset > len(result) {
+		offset = len(result)
+	}
+	result = result[offset:]
+	if num >= 0 && num < len(result) {
+		result = result[:num]
+	}
+	return result, nil
+}
+
+func (h *Handler) withGID(ctx context.Context, params []json.RawMessage, action func(context.Context, int64) error) (interface{}, error) {
+	id, err := gidParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := action(ctx, id); err != nil {
+		return nil, err
+	}
+	return formatGID(id), nil
+}
+
+func (h *Handler) unpause(ctx context.Context, id int64) error {
+	err := h.queueManager.ResumeDownload(ctx, id)
+	if errors.Is(err, queues.ErrDownloadInProgress) {
+		return &rpcError{Code: codeFailure, Message: fmt.Sprintf("GID %s is not paused", formatGID(id))}
+	}
+	return err
+}
+
+func (h *Handler) globalStat(ctx context.Context) (interface{}, error) {
+	rows, err := h.queueManager.ListDownloadsWithQueueName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var speed float64
+	var active, waiting, stopped int
+	for _, row := range rows {
+		switch aria2Status(row.State) {
+		case statusActive:
+			active++
+			if progress, _, ok := h.cache.get(row.ID); ok {
+				speed += progress.Speed
+			}
+		case statusWaiting, statusPaused:
+			waiting++
+		default:
+			stopped++
+		}
+	}
+
+	return map[string]string{
+		"downloadSpeed":   fmt.Sprint(int64(speed)),
+		"uploadSpeed":     "0",
+		"numActive":       fmt.Sprint(active),
+		"numWaiting":      fmt.Sprint(waiting),
+		"numStopped":      fmt.Sprint(stopped),
+		"numStoppedTotal": fmt.Sprint(stopped),
+	}, nil
+}
+
+func (h *Handler) findDownload(ctx context.Context, id int64) (state.ListDownloadsWithQueueNameRow, error) {
+	rows, err := h.queueManager.ListDownloadsWithQueueName(ctx)
+	if err != nil {
+		return state.ListDownloadsWithQueueNameRow{}, err
+	}
+
+	for _, row := range rows {
+		if row.ID == id {
+			return row, nil
+		}
+	}
+	return state.ListDownloadsWithQueueNameRow{}, fmt.Errorf("GID %s is not found", formatGID(id))
+}
+
+func gidParam(params []json.RawMessage) (int64, error) {
+	var gid string
+	if len(params) == 0 || json.Unmarshal(params[0], &gid) != nil {
+		return 0, errors.New("GID is required")
+	}
+	return parseGID(gid)
+}
+
+func keysParam(params []json.RawMessage, index int) []string {
+	var keys []string
+	if len(params) > index {
+		_ = json.Unmarshal(params[index], &keys)
+	}
+	return keys
+}
+
+func writeResponse(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json-rpc")
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("could not write aria2 rpc response", "error", err)
+	}
+}
@@ -0,0 +1,151 @@
+package aria2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+)
+
+const testToken = "secret"
+
+type fakeQueueManager struct {
+	queues.QueueManager
+
+	running map[int64]bool
+	resumed []int64
+}
+
+func (f *fakeQueueManager) ResumeDownload(_ context.Context, id int64) error {
+	if f.running[id] {
+		return fmt.Errorf("%w: %d", queues.ErrDownloadInProgress, id)
+	}
+	f.running[id] = true
+	f.resumed = append(f.resumed, id)
+	return nil
+}
+
+func callRPC(t *testing.T, handler http.Handler, method string, params ...interface{}) rpcResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      "1",
+		"method":  method,
+		"params":  append([]interface{}{tokenPrefix + testToken}, params...),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jsonrpc", strings.NewReader(string(body))))
+
+	var resp rpcResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not decode response %q: %v", recorder.Body.String(), err)
+	}
+	return resp
+}
+
+func TestUnpause(t *testing.T) {
+	manager := &fakeQueueManager{running: map[int64]bool{}}
+	handler := NewHandler(manager, testToken)
+	gid := formatGID(7)
+
+	resp := callRPC(t, handler, "aria2.unpause", gid)
+	if resp.Error != nil {
+		t.Fatalf("aria2.unpause of a paused download error = %v", resp.Error)
+	}
+	if resp.Result != gid {
+		t.Errorf("aria2.unpause result = %v, want %s", resp.Result, gid)
+	}
+
+	resp = callRPC(t, handler, "aria2.unpause", gid)
+	if resp.Error == nil {
+		t.Fatal("aria2.unpause of an active download error = nil, want an error")
+	}
+	if want := fmt.Sprintf("GID %s is not paused", gid); resp.Error.Code != codeFailure || resp.Error.Message != want {
+		t.Errorf("aria2.unpause of an active download error = %d %q, want %d %q", resp.Error.Code, resp.Error.Message, codeFailure, want)
+	}
+
+	if len(manager.resumed) != 1 {
+		t.Errorf("download resumed %d times, want once", len(manager.resumed))
+	}
+}
+
+func TestServeHTTPCORS(t *testing.T) {
+	handler := NewHandler(&fakeQueueManager{running: map[int64]bool{}}, testToken)
+
+	tests := []struct {
+		name        string
+		method      string
+		header      http.Header
+		body        string
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "preflight",
+			method:     http.MethodOptions,
+			header:     http.Header{"Origin": {"http://ariang.local"}, "Access-Control-Request-Method": {"POST"}},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "POST, OPTIONS",
+				"Access-Control-Allow-Headers": "Content-Type",
+			},
+		},
+		{
+			name:   "preflight with requested headers",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"moz-extension://abc"},
+				"Access-Control-Request-Method":  {"POST"},
+				"Access-Control-Request-Headers": {"content-type, x-requested-with"},
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Headers": "content-type, x-requested-with"},
+		},
+		{
+			name:        "post",
+			method:      http.MethodPost,
+			header:      http.Header{"Origin": {"http://ariang.local"}, "Content-Type": {"application/json"}},
+			body:        `{"jsonrpc":"2.0","id":"1","method":"system.listMethods","params":[]}`,
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "*"},
+		},
+		{
+			name:        "get",
+			method:      http.MethodGet,
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantHeaders: map[string]string{"Allow": "POST, OPTIONS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/jsonrpc", strings.NewReader(tt.body))
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("%s status = %d, want %d", tt.method, recorder.Code, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := recorder.Header().Get(name); got != want {
+					t.Errorf("%s header %s = %q, want %q", tt.method, name, got, want)
+				}
+			}
+		})
+	}
+}
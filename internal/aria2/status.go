@@ -0,0 +1,171 @@
+package aria2
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const (
+	statusActive   = "active"
+	statusWaiting  = "waiting"
+	statusPaused   = "paused"
+	statusError    = "error"
+	statusComplete = "complete"
+
+	errorCodeUnknown  = "1"
+	errorCodeChecksum = "32"
+)
+
+type statusCache struct {
+	mu       sync.RWMutex
+	progress map[int64]downloads.DownloadStatus
+	failures map[int64]string
+}
+
+func newStatusCache() *statusCache {
+	return &statusCache{
+		progress: make(map[int64]downloads.DownloadStatus),
+		failures: make(map[int64]string),
+	}
+}
+
+func (c *statusCache) run(ctx context.Context, subscription <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-subscription:
+			c.apply(event)
+		}
+	}
+}
+
+func (c *statusCache) apply(event events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.EventType {
+	case events.DownloadProgressed, events.DownloadCompleted:
+		status := event.Payload.(downloads.DownloadStatus)
+		c.progress[status.ID] = status
+		delete(c.failures, status.ID)
+	case events.DownloadFailed:
+		failed := event.Payload.(events.DownloadFailedEvent)
+		if failed.Error != nil {
+			c.failures[failed.ID] = failed.Error.Error()
+		}
+	case events.DownloadDeleted:
+		id := event.Payload.(int64)
+		delete(c.progress, id)
+		delete(c.failures, id)
+	}
+}
+
+func (c *statusCache) get(id int64) (downloads.DownloadStatus, string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status, ok := c.progress[id]
+	return status, c.failures[id], ok
+}
+
+func formatGID(id int64) string {
+	return fmt.Sprintf("%016x", id)
+}
+
+func parseGID(gid string) (int64, error) {
+	id, err := strconv.ParseInt(gid, 16, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("GID %s is not found", gid)
+	}
+	return id, nil
+}
+
+func aria2Status(downloadState string) string {
+	switch downloads.DownloadState(downloadState) {
+	case downloads.StateInProgress, downloads.StateVerifying:
+		return statusActive
+	case downloads.StatePaused:
+		return statusPaused
+	case downloads.StateFailed, downloads.StateChecksumMismatch:
+		return statusError
+	case downloads.StateCompleted:
+		return statusComplete
+	default:
+		return statusWaiting
+	}
+}
+
+func (c *statusCache) describe(download state.ListDownloadsWithQueueNameRow, keys []string) map[string]interface{} {
+	progress, failure, ok := c.get(download.ID)
+	if failure == "" {
+		failure = download.LastError.String
+	}
+
+	totalLength, completedLength := download.TotalSize.Int64, download.BytesDownloaded
+	var speed float64
+	if ok {
+		completedLength = progress.Downloaded
+		if progress.SizeKnown() {
+			totalLength = progress.TotalSize
+		}
+		if download.State == string(downloads.StateInProgress) {
+			speed = progress.Speed
+		}
+	}
+
+	connections := 0
+	if download.State == string(downloads.StateInProgress) {
+		connections = len(progress.DownloadChuncks)
+	}
+
+	length, completed := strconv.FormatInt(totalLength, 10), strconv.FormatInt(completedLength, 10)
+
+	fields := map[string]interface{}{
+		"gid":             formatGID(download.ID),
+		"status":          aria2Status(download.State),
+		"totalLength":     length,
+		"completedLength": completed,
+		"uploadLength":    "0",
+		"downloadSpeed":   strconv.FormatInt(int64(speed), 10),
+		"uploadSpeed":     "0",
+		"connections":     strconv.Itoa(connections),
+		"dir":             filepath.Dir(download.SavePath),
+		"files": []map[string]interface{}{{
+			"index":           "1",
+			"path":            download.SavePath,
+			"length":          length,
+			"completedLength": completed,
+			"selected":        "true",
+			"uris":            []map[string]string{{"uri": download.Url, "status": "used"}},
+		}},
+	}
+
+	switch downloads.DownloadState(download.State) {
+	case downloads.StateFailed:
+		fields["errorCode"] = errorCodeUnknown
+		fields["errorMessage"] = failure
+	case downloads.StateChecksumMismatch:
+		fields["errorCode"] = errorCodeChecksum
+		fields["errorMessage"] = failure
+	}
+
+	if len(keys) == 0 {
+		return fields
+	}
+
+	selected := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,253 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/datadir"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const (
+	socketFileName = "download-manager.sock"
+	dialTimeout    = time.Second
+)
+
+var _ queues.QueueManager = (*Client)(nil)
+
+type Client struct {
+	socketPath string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID uint64
+}
+
+func SocketPath() (string, error) {
+	appDataDir, err := datadir.GetAppDataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get app data directory: %w", err)
+	}
+
+	return filepath.Join(appDataDir, socketFileName), nil
+}
+
+func Dial(socketPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to daemon at %s: %w", socketPath, err)
+	}
+
+	return &Client{
+		socketPath: socketPath,
+		conn:       conn,
+		reader:     bufio.NewReader(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) ensureConnLocked() error {
+	if c.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("could not reconnect to daemon at %s: %w", c.socketPath, err)
+	}
+
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (c *Client) dropConnLocked() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	req := request{Method: method}
+
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("could not encode params for %s: %w", method, err)
+		}
+		req.Params = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureConnLocked(); err != nil {
+		return err
+	}
+
+	c.nextID++
+	req.ID = c.nextID
+
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		c.dropConnLocked()
+		return fmt.Errorf("could not set deadline: %w", err)
+	}
+
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		c.dropConnLocked()
+		return fmt.Errorf("could not send %s to daemon: %w", method, err)
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.dropConnLocked()
+		return fmt.Errorf("could not read %s response from daemon: %w", method, err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("could not decode %s response: %w", method, err)
+	}
+
+	if resp.ID != req.ID {
+		c.dropConnLocked()
+		return fmt.Errorf("daemon response id %d does not match request id %d", resp.ID, req.ID)
+	}
+
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("could not decode %s result: %w", method, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) RelayEvents(ctx context.Context, out chan<- events.Event) error {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("could not connect to daemon event stream: %w", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	if err := json.NewEncoder(conn).Encode(request{Method: methodSubscribe}); err != nil {
+		return fmt.Errorf("could not subscribe to daemon events: %w", err)
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var wire wireEvent
+		if err := decoder.Decode(&wire); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("daemon event stream closed: %w", err)
+		}
+
+		event, err := decodeEvent(wire)
+		if err != nil {
+			slog.Error("could not decode daemon event", "error", err)
+			continue
+		}
+
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *Client) PauseDownload(ctx context.Context, id int64) error {
+	return c.call(ctx, methodPauseDownload, idParams{ID: id}, nil)
+}
+
+func (c *Client) ResumeDownload(ctx context.Context, id int64) error {
+	return c.call(ctx, methodResumeDownload, idParams{ID: id}, nil)
+}
+
+func (c *Client) RetryDownload(ctx context.Context, id int64) error {
+	return c.call(ctx, methodRetryDownload, idParams{ID: id}, nil)
+}
+
+func (c *Client) CreateDownload(ctx context.Context, params queues.CreateDownloadParams) (int64, error) {
+	var id int64
+	err := c.call(ctx, methodCreateDownload, params, &id)
+	return id, err
+}
+
+func (c *Client) DeleteDownload(ctx context.Context, id int64) error {
+	return c.call(ctx, methodDeleteDownload, idParams{ID: id}, nil)
+}
+
+func (c *Client) CreateQueue(ctx context.Context, createQueueParams state.CreateQueueParams) error {
+	return c.call(ctx, methodCreateQueue, createQueueParams, nil)
+}
+
+func (c *Client) DeleteQueue(ctx context.Context, id int64) error {
+	return c.call(ctx, methodDeleteQueue, idParams{ID: id}, nil)
+}
+
+func (c *Client) ListQueue(ctx context.Context) ([]state.Queue, error) {
+	var queues []state.Queue
+	err := c.call(ctx, methodListQueue, nil, &queues)
+	return queues, err
+}
+
+func (c *Client) EditQueue(ctx context.Context, arg state.UpdateQueueParams) error {
+	return c.call(ctx, methodEditQueue, arg, nil)
+}
+
+func (c *Client) SetCredential(ctx context.Context, arg state.UpsertCredentialParams) error {
+	return c.call(ctx, methodSetCredential, arg, nil)
+}
+
+func (c *Client) ListCredentials(ctx context.Context) ([]state.Credential, error) {
+	var credentials []state.Credential
+	err := c.call(ctx, methodListCredentials, nil, &credentials)
+	return credentials, err
+}
+
+func (c *Client) DeleteCredential(ctx context.Context, host string) error {
+	return c.call(ctx, methodDeleteCredential, hostParams{Host: host}, nil)
+}
+
+func (c *Client) ListDownloadsWithQueueName(ctx context.Context) ([]state.ListDownloadsWithQueueNameRow, error) {
+	var downloads []state.ListDownloadsWithQueueNameRow
+	err := c.call(ctx, methodListDownloadsWithQueueName, nil, &downloads)
+	return downloads, err
+}
@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const (
+	methodSubscribe                  = "Subscribe"
+	methodPauseDownload              = "PauseDownload"
+	methodResumeDownload             = "ResumeDownload"
+	methodRetryDownload              = "RetryDownload"
+	methodCreateDownload             = "CreateDownload"
+	methodDeleteDownload             = "DeleteDownload"
+	methodCreateQueue                = "CreateQueue"
+	methodDeleteQueue                = "DeleteQueue"
+	methodListQueue                  = "ListQueue"
+	methodEditQueue                  = "EditQueue"
+	methodSetCredential              = "SetCredential"
+	methodListCredentials            = "ListCredentials"
+	methodDeleteCredential           = "DeleteCredential"
+	methodListDownloadsWithQueueName = "ListDownloadsWithQueueName"
+)
+
+type request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type idParams struct {
+	ID int64 `json:"id"`
+}
+
+type hostParams struct {
+	Host string `json:"host"`
+}
+
+type wireEvent struct {
+	Type    events.EventType `json:"type"`
+	Payload json.RawMessage  `json:"payload"`
+}
+
+type wireDownloadFailedEvent struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"`
+	Error     string `json:"error"`
+	Kind      string `json:"kind,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"`
+}
+
+func encodeEvent(event events.Event) (wireEvent, error) {
+	payload := event.Payload
+
+	if failed, ok := payload.(events.DownloadFailedEvent); ok {
+		wireFailed := wireDownloadFailedEvent{ID: failed.ID, URL: failed.URL, Kind: failed.Kind, Permanent: failed.Permanent}
+		if failed.Error != nil {
+			wireFailed.Error = failed.Error.Error()
+		}
+		payload = wireFailed
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return wireEvent{}, fmt.Errorf("could not encode event payload: %w", err)
+	}
+
+	return wireEvent{Type: event.EventType, Payload: data}, nil
+}
+
+func decodeEvent(event wireEvent) (events.Event, error) {
+	var payload interface{}
+	var err error
+
+	switch event.Type {
+	case events.DownloadFailed:
+		var failed wireDownloadFailedEvent
+		err = json.Unmarshal(event.Payload, &failed)
+		payload = events.DownloadFailedEvent{
+			ID:        failed.ID,
+			URL:       failed.URL,
+			Error:     remoteError(failed.Kind, failed.Permanent, failed.Error),
+			Kind:      failed.Kind,
+			Permanent: failed.Permanent,
+		}
+	case events.DownloadProgressed, events.DownloadCompleted, events.DownloadVerifying:
+		payload, err = unmarshalAs[downloads.DownloadStatus](event.Payload)
+	case events.DownloadProbed:
+		payload, err = unmarshalAs[events.DownloadProbedEvent](event.Payload)
+	case events.DownloadStateChanged:
+		payload, err = unmarshalAs[state.SetDownloadStateParams](event.Payload)
+	case events.QueueCreated, events.QueueEdited:
+		payload, err = unmarshalAs[state.Queue](event.Payload)
+	case events.QueueDeleted, events.DownloadDeleted:
+		payload, err = unmarshalAs[int64](event.Payload)
+	case events.DownloadCreated:
+		payload, err = unmarshalAs[state.ListDownloadsWithQueueNameRow](event.Payload)
+	default:
+		return events.Event{}, fmt.Errorf("unknown event type %d", event.Type)
+	}
+
+	if err != nil {
+		return events.Event{}, fmt.Errorf("could not decode event payload: %w", err)
+	}
+
+	return events.Event{EventType: event.Type, Payload: payload}, nil
+}
+
+func unmarshalAs[T any](data json.RawMessage) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+func remoteError(kind string, permanent bool, message string) error {
+	if kind == "" {
+		return errors.New(message)
+	}
+	return &downloads.RemoteError{Kind: downloads.ErrorKind(kind), Permanent: permanent, Message: message}
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/queues"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+var ErrDaemonRunning = errors.New("download manager daemon is already running")
+
+type Server struct {
+	queueManager queues.QueueManager
+	broadcaster  *events.Broadcaster
+}
+
+func NewServer(queueManager queues.QueueManager, broadcaster *events.Broadcaster) *Server {
+	return &Server{
+		queueManager: queueManager,
+		broadcaster:  broadcaster,
+	}
+}
+
+func Listen(socketPath string) (net.Listener, error) {
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, ErrDaemonRunning
+		}
+
+		slog.Warn("removing stale daemon socket", "path", socketPath)
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("could not remove stale socket %s: %w", socketPath, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %w", socketPath, err)
+	}
+
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("could not restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("could not accept connection: %w", err)
+		}
+
+		go s.handleConn(ctx, conn)
+	}
+}
+
+func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	encoder := json.NewEncoder(conn)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			slog.Error("could not decode daemon request", "error", err)
+			_ = encoder.Encode(response{Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+
+		if req.Method == methodSubscribe {
+			s.streamEvents(ctx, encoder)
+			return
+		}
+
+		resp := response{ID: req.ID}
+
+		result, err := s.dispatch(ctx, req)
+		if err != nil {
+			resp.Error = err.Error()
+		} else if result != nil {
+			resp.Result, err = json.Marshal(result)
+			if err != nil {
+				resp.Error = fmt.Sprintf("could not encode result: %v", err)
+			}
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			slog.Error("could not write daemon response", "error", err)
+			return
+		}
+	}
+}
+
+func (s *Server) streamEvents(ctx context.Context, encoder *json.Encoder) {
+	subscription, unsubscribe := s.broadcaster.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-subscription:
+			wire, err := encodeEvent(event)
+			if err != nil {
+				slog.Error("could not encode event for subscriber", "eventType", event.EventType, "error", err)
+				continue
+			}
+
+			if err := encoder.Encode(wire); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, error) {
+	qm := s.queueManager
+
+	switch req.Method {
+	case methodPauseDownload:
+		return withParams(req, func(p idParams) (interface{}, error) { return nil, qm.PauseDownload(ctx, p.ID) })
+	case methodResumeDownload:
+		return withParams(req, func(p idParams) (interface{}, error) { return nil, qm.ResumeDownload(ctx, p.ID) })
+	case methodRetryDownload:
+		return withParams(req, func(p idParams) (interface{}, error) { return nil, qm.RetryDownload(ctx, p.ID) })
+	case methodDeleteDownload:
+		return withParams(req, func(p idParams) (interface{}, error) { return nil, qm.DeleteDownload(ctx, p.ID) })
+	case methodCreateDownload:
+		return withParams(req, func(p queues.CreateDownloadParams) (interface{}, error) { return qm.CreateDownload(ctx, p) })
+	case methodCreateQueue:
+		return withParams(req, func(p state.CreateQueueParams) (interface{}, error) { return nil, qm.CreateQueue(ctx, p) })
+	case methodDeleteQueue:
+		return withParams(req, func(p idParams) (interface{}, error) { return nil, qm.DeleteQueue(ctx, p.ID) })
+	case methodEditQueue:
+		return withParams(req, func(p state.UpdateQueueParams) (interface{}, error) { return nil, qm.EditQueue(ctx, p) })
+	case methodListQueue:
+		return qm.ListQueue(ctx)
+	case methodSetCredential:
+		return withParams(req, func(p state.UpsertCredentialParams) (interface{}, error) { return nil, qm.SetCredential(ctx, p) })
+	case methodListCredentials:
+		return qm.ListCredentials(ctx)
+	case methodDeleteCredential:
+		return withParams(req, func(p hostParams) (interface{}, error) { return nil, qm.DeleteCredential(ctx, p.Host) })
+	case methodListDownloadsWithQueueName:
+		return qm.ListDownloadsWithQueueName(ctx)
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+func withParams[T any](req request, handle func(T) (interface{}, error)) (interface{}, error) {
+	var params T
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, fmt.Errorf("invalid params for %s: %w", req.Method, err)
+	}
+	return handle(params)
+}
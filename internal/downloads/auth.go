@@ -0,0 +1,320 @@
+package downloads
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+type AuthScheme string
+
+const (
+	AuthBasic  AuthScheme = "basic"
+	AuthDigest AuthScheme = "digest"
+	AuthBearer AuthScheme = "bearer"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+var newCnonce = func() (string, error) {
+	cnonce := make([]byte, 12)
+	if _, err := rand.Read(cnonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(cnonce), nil
+}
+
+type Credentials struct {
+	Host     string
+	Scheme   AuthScheme
+	Username string
+	Secret   string
+
+	OnChallenge bool
+}
+
+func CredentialsFromState(row state.Credential) Credentials {
+	return Credentials{
+		Host:     row.Host,
+		Scheme:   AuthScheme(row.Scheme),
+		Username: row.Username,
+		Secret:   row.Secret,
+	}
+}
+
+func (c Credentials) Validate() error {
+	if c.Host == "" || strings.ContainsAny(c.Host, "/@ ") {
+		return fmt.Errorf("%w: %q is not a valid host", ErrInvalidCredentials, c.Host)
+	}
+
+	switch c.Scheme {
+	case AuthBasic, AuthDigest:
+		if c.Username == "" {
+			return fmt.Errorf("%w: %s authentication needs a username", ErrInvalidCredentials, c.Scheme)
+		}
+	case AuthBearer:
+		if c.Secret == "" {
+			return fmt.Errorf("%w: bearer authentication needs a token", ErrInvalidCredentials)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported scheme %q, use basic, digest or bearer", ErrInvalidCredentials, c.Scheme)
+	}
+
+	return nil
+}
+
+func (c Credentials) matches(target *url.URL) bool {
+	return hostMatches(c.Host, target.Host)
+}
+
+func WithCredentials(client *http.Client, credentials *Credentials) *http.Client {
+	if credentials == nil {
+		return client
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	authenticated := *client
+	authenticated.Transport = &authTransport{base: base, credentials: *credentials}
+	return &authenticated
+}
+
+type authTransport struct {
+	base        http.RoundTripper
+	credentials Credentials
+
+	mu         sync.Mutex
+	challenge  *digestChallenge
+	count      uint32
+	challenged bool
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.credentials.matches(req.URL) || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	authorized := req.Clone(req.Context())
+	sentCredentials := t.preemptive()
+	sentDigest := sentCredentials && t.authorize(authorized)
+
+	resp, err := t.base.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || t.credentials.Scheme == AuthBearer {
+		return resp, err
+	}
+
+	challenges := resp.Header.Values("WWW-Authenticate")
+	challenge := parseDigestChallenge(challenges)
+	answer := challenge != nil && (!sentDigest || challenge.stale) ||
+		challenge == nil && !sentCredentials && hasBasicChallenge(challenges)
+	if !answer || req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.mu.Lock()
+	if challenge != nil {
+		t.challenge = challenge
+		t.count = 0
+	}
+	t.challenged = true
+	t.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	t.authorize(retry)
+
+	return t.base.RoundTrip(retry)
+}
+
+func (t *authTransport) preemptive() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.credentials.OnChallenge || t.challenged
+}
+
+func (t *authTransport) authorize(req *http.Request) bool {
+	switch t.credentials.Scheme {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+t.credentials.Secret)
+		return false
+	}
+
+	t.mu.Lock()
+	challenge := t.challenge
+	if challenge != nil {
+		t.count++
+	}
+	count := t.count
+	t.mu.Unlock()
+
+	if challenge != nil {
+		authorization, err := challenge.authorization(req, t.credentials, count)
+		if err == nil {
+			req.Header.Set("Authorization", authorization)
+			return true
+		}
+	}
+
+	if t.credentials.Scheme == AuthBasic {
+		req.SetBasicAuth(t.credentials.Username, t.credentials.Secret)
+	}
+	return false
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	stale     bool
+}
+
+func hasBasicChallenge(headers []string) bool {
+	for _, header := range headers {
+		scheme, _, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if strings.EqualFold(scheme, "Basic") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDigestChallenge(headers []string) *digestChallenge {
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		params := parseAuthParams(rest)
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			stale:     strings.EqualFold(params["stale"], "true"),
+		}
+		if challenge.nonce == "" {
+			continue
+		}
+
+		for _, qop := range strings.Split(params["qop"], ",") {
+			if strings.TrimSpace(qop) == "auth" {
+				challenge.qop = "auth"
+			}
+		}
+
+		return challenge
+	}
+
+	return nil
+}
+
+func parseAuthParams(value string) map[string]string {
+	params := make(map[string]string)
+
+	for value = strings.TrimSpace(value); value != ""; value = strings.TrimLeft(value, ", ") {
+		key, rest, ok := strings.Cut(value, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		var param string
+		if strings.HasPrefix(rest, `"`) {
+			var sb strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				sb.WriteByte(rest[i])
+			}
+			param, value = sb.String(), rest[min(i+1, len(rest)):]
+		} else {
+			param, value, _ = strings.Cut(rest, ",")
+			param = strings.TrimSpace(param)
+		}
+
+		params[key] = param
+	}
+
+	return params
+}
+
+func (c *digestChallenge) authorization(req *http.Request, credentials Credentials, count uint32) (string, error) {
+	var newHash func() hash.Hash
+	algorithm := strings.ToUpper(c.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", c.algorithm)
+	}
+
+	digest := func(parts ...string) string {
+		h := newHash()
+		io.WriteString(h, strings.Join(parts, ":"))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	cnonce, err := newCnonce()
+	if err != nil {
+		return "", err
+	}
+	nc := fmt.Sprintf("%08x", count)
+	uri := req.URL.RequestURI()
+
+	ha1 := digest(credentials.Username, c.realm, credentials.Secret)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = digest(ha1, c.nonce, cnonce)
+	}
+	ha2 := digest(req.Method, uri)
+
+	var response string
+	if c.qop == "" {
+		response = digest(ha1, c.nonce, ha2)
+	} else {
+		response = digest(ha1, c.nonce, nc, cnonce, c.qop, ha2)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `Digest username=%q, realm=%q, nonce=%q, uri=%q, response=%q`,
+		credentials.Username, c.realm, c.nonce, uri, response)
+	if c.algorithm != "" {
+		fmt.Fprintf(&sb, `, algorithm=%s`, c.algorithm)
+	}
+	if c.opaque != "" {
+		fmt.Fprintf(&sb, `, opaque=%q`, c.opaque)
+	}
+	if c.qop != "" {
+		fmt.Fprintf(&sb, `, qop=%s, nc=%s, cnonce=%q`, c.qop, nc, cnonce)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,429 @@
+package downloads
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    *digestChallenge
+	}{
+		{
+			name:    "rfc 7616 example",
+			headers: []string{`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`},
+			want: &digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: "SHA-256",
+				qop:       "auth",
+			},
+		},
+		{
+			name:    "quoted commas and escapes",
+			headers: []string{`digest realm="files, \"private\"",nonce="abc",stale=TRUE`},
+			want:    &digestChallenge{realm: `files, "private"`, nonce: "abc", stale: true},
+		},
+		{
+			name:    "only auth-int",
+			headers: []string{`Digest realm="r", nonce="n", qop="auth-int"`},
+			want:    &digestChallenge{realm: "r", nonce: "n"},
+		},
+		{
+			name:    "after a basic challenge",
+			headers: []string{`Basic realm="r"`, `Digest realm="r", nonce="n", qop=auth`},
+			want:    &digestChallenge{realm: "r", nonce: "n", qop: "auth"},
+		},
+		{
+			name:    "missing nonce",
+			headers: []string{`Digest realm="r", qop="auth"`},
+		},
+		{
+			name:    "basic only",
+			headers: []string{`Basic realm="r"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDigestChallenge(tt.headers)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDigestChallenge(%q) = %+v, want %+v", tt.headers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	tests := []struct {
+		name        string
+		challenge   digestChallenge
+		credentials Credentials
+		cnonce      string
+		want        string
+	}{
+		{
+			name: "rfc 2617 md5",
+			challenge: digestChallenge{
+				realm:  "testrealm@host.com",
+				nonce:  "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+				opaque: "5ccc069c403ebaf9f0171e9517f40e41",
+				qop:    "auth",
+			},
+			credentials: Credentials{Username: "Mufasa", Secret: "Circle Of Life"},
+			cnonce:      "0a4f113b",
+			want:        "6629fae49393a05397450978507c4ef1",
+		},
+		{
+			name: "rfc 7616 md5",
+			challenge: digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: "MD5",
+				qop:       "auth",
+			},
+			credentials: Credentials{Username: "Mufasa", Secret: "Circle of Life"},
+			cnonce:      "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			want:        "8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			name: "rfc 7616 sha-256",
+			challenge: digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: "SHA-256",
+				qop:       "auth",
+			},
+			credentials: Credentials{Username: "Mufasa", Secret: "Circle of Life"},
+			cnonce:      "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			want:        "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := newCnonce
+			newCnonce = func() (string, error) { return tt.cnonce, nil }
+			defer func() { newCnonce = restore }()
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.org/dir/index.html", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			authorization, err := tt.challenge.authorization(req, tt.credentials, 1)
+			if err != nil {
+				t.Fatalf("authorization() error = %v", err)
+			}
+
+			scheme, rest, _ := strings.Cut(authorization, " ")
+			params := parseAuthParams(rest)
+			if scheme != "Digest" || params["response"] != tt.want {
+				t.Errorf("authorization() = %s, want response %s", authorization, tt.want)
+			}
+			if params["nc"] != "00000001" || params["cnonce"] != tt.cnonce || params["uri"] != "/dir/index.html" || params["opaque"] != tt.challenge.opaque {
+				t.Errorf("authorization() = %s, want nc 00000001, cnonce %s, uri /dir/index.html and opaque %s", authorization, tt.cnonce, tt.challenge.opaque)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.org/", nil)
+	if _, err := (&digestChallenge{nonce: "n", algorithm: "SHA-512-256"}).authorization(req, Credentials{Username: "u"}, 1); err == nil {
+		t.Error("authorization() with an unsupported algorithm error = nil, want an error")
+	}
+}
+
+type digestServer struct {
+	username, password string
+	realm, algorithm   string
+	qop                string
+	rotateAfter        int
+
+	mu         sync.Mutex
+	nonces     []string
+	authorized int
+	counts     []string
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.nonces) == 0 {
+		s.nonces = append(s.nonces, "nonce-1")
+	}
+	current := s.nonces[len(s.nonces)-1]
+
+	scheme, rest, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	params := parseAuthParams(rest)
+	if scheme != "Digest" {
+		s.challenge(w, current, false)
+		return
+	}
+
+	if params["nonce"] != current {
+		s.challenge(w, current, true)
+		return
+	}
+	if params["response"] != s.expected(r, params) {
+		s.challenge(w, current, false)
+		return
+	}
+
+	s.counts = append(s.counts, params["nc"])
+	s.authorized++
+	if s.rotateAfter > 0 && s.authorized%s.rotateAfter == 0 {
+		s.nonces = append(s.nonces, fmt.Sprintf("nonce-%d", len(s.nonces)+1))
+	}
+}
+
+func (s *digestServer) challenge(w http.ResponseWriter, nonce string, stale bool) {
+	challenge := fmt.Sprintf(`Digest realm=%q, nonce=%q, opaque="opaque-value"`, s.realm, nonce)
+	if s.qop != "" {
+		challenge += fmt.Sprintf(`, qop="%s"`, s.qop)
+	}
+	if s.algorithm != "" {
+		challenge += ", algorithm=" + s.algorithm
+	}
+	if stale {
+		challenge += ", stale=true"
+	}
+	w.Header().Add("WWW-Authenticate", `Basic realm="fallback"`)
+	w.Header().Add("WWW-Authenticate", challenge)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *digestServer) expected(r *http.Request, params map[string]string) string {
+	newHash := md5.New
+	if s.algorithm == "SHA-256" {
+		newHash = sha256.New
+	}
+	digest := func(h hash.Hash, parts ...string) string {
+		io.WriteString(h, strings.Join(parts, ":"))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	ha1 := digest(newHash(), s.username, s.realm, s.password)
+	ha2 := digest(newHash(), r.Method, params["uri"])
+	if params["uri"] != r.URL.RequestURI() {
+		return ""
+	}
+	if params["qop"] == "" {
+		return digest(newHash(), ha1, params["nonce"], ha2)
+	}
+	return digest(newHash(), ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+}
+
+func TestAuthTransportDigest(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     *digestServer
+		secret     string
+		requests   int
+		wantStatus int
+		wantCounts []string
+	}{
+		{
+			name:       "md5 with qop",
+			server:     &digestServer{qop: "auth"},
+			requests:   3,
+			wantStatus: http.StatusOK,
+			wantCounts: []string{"00000001", "00000002", "00000003"},
+		},
+		{
+			name:       "sha-256 with qop",
+			server:     &digestServer{qop: "auth", algorithm: "SHA-256"},
+			requests:   2,
+			wantStatus: http.StatusOK,
+			wantCounts: []string{"00000001", "00000002"},
+		},
+		{
+			name:       "without qop",
+			server:     &digestServer{},
+			requests:   2,
+			wantStatus: http.StatusOK,
+			wantCounts: []string{"", ""},
+		},
+		{
+			name:       "stale nonce",
+			server:     &digestServer{qop: "auth", rotateAfter: 2},
+			requests:   4,
+			wantStatus: http.StatusOK,
+			wantCounts: []string{"00000001", "00000002", "00000001", "00000002"},
+		},
+		{
+			name:       "wrong password",
+			server:     &digestServer{qop: "auth"},
+			secret:     "wrong",
+			requests:   1,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.server.username, tt.server.password, tt.server.realm = "alice", "s3cret", "files"
+			server := httptest.NewServer(tt.server)
+			defer server.Close()
+
+			secret := tt.secret
+			if secret == "" {
+				secret = "s3cret"
+			}
+			credentials := Credentials{Host: server.Listener.Addr().String(), Scheme: AuthDigest, Username: "alice", Secret: secret}
+			client := WithCredentials(server.Client(), &credentials)
+
+			for i := 0; i < tt.requests; i++ {
+				resp, err := client.Get(server.URL + "/files/archive.zip?part=" + fmt.Sprint(i))
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode != tt.wantStatus {
+					t.Fatalf("request %d status = %d, want %d", i, resp.StatusCode, tt.wantStatus)
+				}
+			}
+
+			if !reflect.DeepEqual(tt.server.counts, tt.wantCounts) {
+				t.Errorf("nonce counts = %q, want %q", tt.server.counts, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func TestAuthTransportBearer(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Authorization"))
+		if r.URL.Path == "/denied" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	credentials := Credentials{Host: server.Listener.Addr().String(), Scheme: AuthBearer, Secret: "token-123"}
+	client := WithCredentials(server.Client(), &credentials)
+
+	for _, path := range []string{"/file", "/denied"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/file", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer explicit")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	want := []string{"Bearer token-123", "Bearer token-123", "Bearer explicit"}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("Authorization headers = %q, want %q", received, want)
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("credentials for %s sent to another host: %q", credentials.Host, auth)
+		}
+	}))
+	defer other.Close()
+
+	resp, err = client.Get(other.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestAuthTransportOnChallenge(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials Credentials
+		challenge   bool
+		wantStatus  int
+		wantSent    []bool
+	}{
+		{
+			name:        "sent up front",
+			credentials: Credentials{Scheme: AuthBasic, Username: "alice", Secret: "s3cret"},
+			challenge:   true,
+			wantStatus:  http.StatusOK,
+			wantSent:    []bool{true},
+		},
+		{
+			name:        "sent after a challenge",
+			credentials: Credentials{Scheme: AuthBasic, Username: "anonymous", Secret: "guest", OnChallenge: true},
+			challenge:   true,
+			wantStatus:  http.StatusOK,
+			wantSent:    []bool{false, true},
+		},
+		{
+			name:        "never sent without a challenge",
+			credentials: Credentials{Scheme: AuthBasic, Username: "anonymous", Secret: "guest", OnChallenge: true},
+			wantStatus:  http.StatusOK,
+			wantSent:    []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _, ok := r.BasicAuth()
+				sent = append(sent, ok)
+				if tt.challenge && !ok {
+					w.Header().Set("WWW-Authenticate", `Basic realm="files"`)
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer server.Close()
+
+			credentials := tt.credentials
+			credentials.Host = server.Listener.Addr().String()
+			client := WithCredentials(server.Client(), &credentials)
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(sent) != len(tt.wantSent) {
+				t.Fatalf("requests sent credentials %v, want %v", sent, tt.wantSent)
+			}
+			for i := range sent {
+				if sent[i] != tt.wantSent[i] {
+					t.Errorf("requests sent credentials %v, want %v", sent, tt.wantSent)
+					break
+				}
+			}
+		})
+	}
+}
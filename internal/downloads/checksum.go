@@ -0,0 +1,116 @@
+package downloads
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumSHA512 ChecksumAlgorithm = "sha512"
+)
+
+var checksumHashes = map[ChecksumAlgorithm]struct {
+	new  func() hash.Hash
+	size int
+}{
+	ChecksumMD5:    {md5.New, md5.Size},
+	ChecksumSHA1:   {sha1.New, sha1.Size},
+	ChecksumSHA256: {sha256.New, sha256.Size},
+	ChecksumSHA512: {sha512.New, sha512.Size},
+}
+
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Digest    []byte
+}
+
+type ChecksumMismatchError struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+func ParseChecksum(value string) (Checksum, error) {
+	value = strings.TrimSpace(value)
+
+	separator := strings.IndexAny(value, ":=")
+	if separator < 0 {
+		return Checksum{}, fmt.Errorf("invalid checksum %q, expected <algorithm>:<hex digest>", value)
+	}
+
+	name := strings.ReplaceAll(strings.ToLower(value[:separator]), "-", "")
+	algorithm := ChecksumAlgorithm(name)
+
+	hashFunc, ok := checksumHashes[algorithm]
+	if !ok {
+		return Checksum{}, fmt.Errorf("unsupported checksum algorithm %q, use md5, sha1, sha256 or sha512", value[:separator])
+	}
+
+	digest, err := hex.DecodeString(strings.TrimSpace(value[separator+1:]))
+	if err != nil {
+		return Checksum{}, fmt.Errorf("invalid %s digest: %w", algorithm, err)
+	}
+	if len(digest) != hashFunc.size {
+		return Checksum{}, fmt.Errorf("invalid %s digest: expected %d bytes, got %d", algorithm, hashFunc.size, len(digest))
+	}
+
+	return Checksum{Algorithm: algorithm, Digest: digest}, nil
+}
+
+func (c Checksum) String() string {
+	return fmt.Sprintf("%s:%s", c.Algorithm, hex.EncodeToString(c.Digest))
+}
+
+func (c Checksum) Verify(path string) error {
+	hashFunc, ok := checksumHashes[c.Algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported checksum algorithm %q", c.Algorithm)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return &DiskError{Err: fmt.Errorf("could not open %s for verification: %w", path, err)}
+	}
+	defer file.Close()
+
+	h := hashFunc.new()
+	if _, err := io.Copy(h, file); err != nil {
+		return &DiskError{Err: fmt.Errorf("could not read %s for verification: %w", path, err)}
+	}
+
+	actual := h.Sum(nil)
+	if !bytes.Equal(actual, c.Digest) {
+		return &IntegrityError{Err: &ChecksumMismatchError{
+			Algorithm: c.Algorithm,
+			Expected:  hex.EncodeToString(c.Digest),
+			Actual:    hex.EncodeToString(actual),
+		}}
+	}
+
+	return nil
+}
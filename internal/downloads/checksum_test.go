@@ -0,0 +1,86 @@
+package downloads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+	helloSHA1   = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	helloSHA512 = "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"
+)
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "md5:" + helloMD5, want: "md5:" + helloMD5},
+		{value: "sha1=" + helloSHA1, want: "sha1:" + helloSHA1},
+		{value: "SHA-256:" + helloSHA256, want: "sha256:" + helloSHA256},
+		{value: "  sha512: " + helloSHA512 + "  ", want: "sha512:" + helloSHA512},
+		{value: "sha256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824", want: "sha256:" + helloSHA256},
+		{value: helloSHA256, wantErr: true},
+		{value: "crc32:3610a686", wantErr: true},
+		{value: "sha256:xyz", wantErr: true},
+		{value: "sha256:" + helloMD5, wantErr: true},
+		{value: "md5:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseChecksum(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChecksum(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseChecksum(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		checksum     string
+		path         string
+		wantMismatch bool
+		wantDisk     bool
+	}{
+		{checksum: "md5:" + helloMD5, path: path},
+		{checksum: "sha256:" + helloSHA256, path: path},
+		{checksum: "sha1:" + helloMD5 + "00000000", path: path, wantMismatch: true},
+		{checksum: "sha256:" + helloSHA256, path: path + ".missing", wantDisk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.checksum, func(t *testing.T) {
+			checksum, err := ParseChecksum(tt.checksum)
+			if err != nil {
+				t.Fatalf("ParseChecksum(%q) error = %v", tt.checksum, err)
+			}
+
+			err = checksum.Verify(tt.path)
+			if got := errors.Is(err, ErrChecksumMismatch); got != tt.wantMismatch {
+				t.Errorf("Verify() error = %v, want checksum mismatch %v", err, tt.wantMismatch)
+			}
+			var diskErr *DiskError
+			if got := errors.As(err, &diskErr); got != tt.wantDisk {
+				t.Errorf("Verify() error = %v, want disk error %v", err, tt.wantDisk)
+			}
+			if !tt.wantMismatch && !tt.wantDisk && err != nil {
+				t.Errorf("Verify() error = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package downloads
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type ConflictPolicy string
+
+const (
+	ConflictRename    ConflictPolicy = "rename"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictResume    ConflictPolicy = "resume"
+
+	DefaultConflictPolicy = ConflictRename
+)
+
+var (
+	ErrInvalidConflictPolicy = errors.New("invalid conflict policy")
+	ErrFileExists            = errors.New("file already exists")
+)
+
+var conflictPolicies = []ConflictPolicy{ConflictRename, ConflictOverwrite, ConflictSkip, ConflictResume}
+
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	if value == "" {
+		return DefaultConflictPolicy, nil
+	}
+
+	for _, policy := range conflictPolicies {
+		if strings.EqualFold(value, string(policy)) {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q, use rename, overwrite, skip or resume", ErrInvalidConflictPolicy, value)
+}
+
+func RenamedSavePath(savePath string, n int) string {
+	dir, name := filepath.Split(savePath)
+
+	ext := filepath.Ext(name)
+	if stem := strings.TrimSuffix(name, ext); stem != "" && strings.EqualFold(filepath.Ext(stem), ".tar") {
+		ext = filepath.Ext(stem) + ext
+	}
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" {
+		stem, ext = name, ""
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+}
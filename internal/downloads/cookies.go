@@ -0,0 +1,153 @@
+package downloads
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	NoCookieFile   = "none"
+	httpOnlyPrefix = "#HttpOnly_"
+)
+
+var ErrInvalidCookieFile = errors.New("invalid cookie file")
+
+type CookieJar struct {
+	path string
+
+	mu       sync.Mutex
+	jar      *cookiejar.Jar
+	modified time.Time
+}
+
+func LoadCookieJar(path string) (*CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cookieJar := &CookieJar{path: path, jar: jar}
+	if err := cookieJar.Refresh(); err != nil {
+		return nil, err
+	}
+	return cookieJar, nil
+}
+
+func ValidateCookieFile(path string) error {
+	if path == "" || path == NoCookieFile {
+		return nil
+	}
+	_, err := LoadCookieJar(path)
+	return err
+}
+
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+}
+
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+func (j *CookieJar) Refresh() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info, err := os.Stat(j.path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCookieFile, err)
+	}
+	if info.ModTime().Equal(j.modified) {
+		return nil
+	}
+
+	file, err := os.Open(j.path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCookieFile, err)
+	}
+	defer file.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		cookieURL, cookie, err := parseCookieLine(scanner.Text(), now)
+		if err != nil {
+			return fmt.Errorf("%w: %s line %d: %w", ErrInvalidCookieFile, j.path, line, err)
+		}
+		if cookie != nil {
+			j.jar.SetCookies(cookieURL, []*http.Cookie{cookie})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCookieFile, err)
+	}
+
+	j.modified = info.ModTime()
+	return nil
+}
+
+func parseCookieLine(line string, now time.Time) (*url.URL, *http.Cookie, error) {
+	line = strings.TrimRight(line, "\r")
+
+	httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+	if httpOnly {
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+	}
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return nil, nil, nil
+	}
+
+	fields := strings.Split(line, "\t")
+	if len(fields) == 6 {
+		fields = append(fields, "")
+	}
+	if len(fields) != 7 {
+		return nil, nil, fmt.Errorf("expected 7 tab separated fields, got %d", len(fields))
+	}
+
+	domain, includeSubdomains, path, secure, expires, name, value :=
+		fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid expiry %q", expires)
+	}
+	if expiry != 0 && time.Unix(expiry, 0).Before(now) {
+		return nil, nil, nil
+	}
+
+	host := strings.TrimPrefix(domain, ".")
+	if host == "" || name == "" {
+		return nil, nil, errors.New("domain and name are required")
+	}
+
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Secure:   strings.EqualFold(secure, "TRUE"),
+		HttpOnly: httpOnly,
+	}
+	if expiry != 0 {
+		cookie.Expires = time.Unix(expiry, 0)
+	}
+	if strings.EqualFold(includeSubdomains, "TRUE") {
+		cookie.Domain = host
+	}
+
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+
+	return &url.URL{Scheme: scheme, Host: host, Path: path}, cookie, nil
+}
@@ -0,0 +1,131 @@
+package downloads
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCookieLine(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	future := "1800000000"
+
+	tests := []struct {
+		name       string
+		line       string
+		wantURL    string
+		wantCookie *http.Cookie
+		wantErr    bool
+	}{
+		{name: "empty", line: ""},
+		{name: "comment", line: "# Netscape HTTP Cookie File"},
+		{name: "blank with carriage return", line: "  \r"},
+		{
+			name:       "host only",
+			line:       "example.com\tFALSE\t/\tFALSE\t" + future + "\tsession\tabc",
+			wantURL:    "http://example.com/",
+			wantCookie: &http.Cookie{Name: "session", Value: "abc", Path: "/", Expires: time.Unix(1800000000, 0)},
+		},
+		{
+			name:       "subdomains",
+			line:       ".example.com\tTRUE\t/files\tTRUE\t" + future + "\ttoken\txyz\r",
+			wantURL:    "https://example.com/files",
+			wantCookie: &http.Cookie{Name: "token", Value: "xyz", Path: "/files", Domain: "example.com", Secure: true, Expires: time.Unix(1800000000, 0)},
+		},
+		{
+			name:       "http only",
+			line:       "#HttpOnly_example.com\tFALSE\t/\tFALSE\t0\tsid\t1",
+			wantURL:    "http://example.com/",
+			wantCookie: &http.Cookie{Name: "sid", Value: "1", Path: "/", HttpOnly: true},
+		},
+		{
+			name:       "empty value",
+			line:       "example.com\tFALSE\t/\tFALSE\t0\tflag",
+			wantURL:    "http://example.com/",
+			wantCookie: &http.Cookie{Name: "flag", Path: "/"},
+		},
+		{name: "expired", line: "example.com\tFALSE\t/\tFALSE\t1600000000\told\tvalue"},
+		{name: "too few fields", line: "example.com\tFALSE\t/\tFALSE", wantErr: true},
+		{name: "spaces instead of tabs", line: "example.com FALSE / FALSE 0 name value", wantErr: true},
+		{name: "invalid expiry", line: "example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue", wantErr: true},
+		{name: "missing domain", line: "\tFALSE\t/\tFALSE\t0\tname\tvalue", wantErr: true},
+		{name: "missing name", line: "example.com\tFALSE\t/\tFALSE\t0\t\tvalue", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotURL, gotCookie, err := parseCookieLine(tt.line, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCookieLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+
+			if tt.wantCookie == nil {
+				if gotCookie != nil {
+					t.Errorf("parseCookieLine(%q) = %+v, want no cookie", tt.line, gotCookie)
+				}
+				return
+			}
+
+			if gotURL == nil || gotURL.String() != tt.wantURL {
+				t.Errorf("parseCookieLine(%q) url = %v, want %s", tt.line, gotURL, tt.wantURL)
+			}
+			if gotCookie == nil || gotCookie.String() != tt.wantCookie.String() || gotCookie.Domain != tt.wantCookie.Domain {
+				t.Errorf("parseCookieLine(%q) cookie = %+v, want %+v", tt.line, gotCookie, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	lines := []string{
+		"# Netscape HTTP Cookie File",
+		".example.com\tTRUE\t/\tFALSE\t0\tshared\tyes",
+		"files.example.com\tFALSE\t/private\tTRUE\t0\tsecret\ttoken",
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	jar, err := LoadCookieJar(path)
+	if err != nil {
+		t.Fatalf("LoadCookieJar() error = %v", err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "http://example.com/", want: "shared=yes"},
+		{target: "http://cdn.example.com/file", want: "shared=yes"},
+		{target: "https://files.example.com/private/file", want: "secret=token; shared=yes"},
+		{target: "http://files.example.com/private/file", want: "shared=yes"},
+		{target: "https://files.example.com/public", want: "shared=yes"},
+		{target: "http://example.org/", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, cookie := range jar.Cookies(target) {
+				got = append(got, cookie.String())
+			}
+			if strings.Join(got, "; ") != tt.want {
+				t.Errorf("Cookies(%s) = %q, want %q", tt.target, strings.Join(got, "; "), tt.want)
+			}
+		})
+	}
+
+	if err := ValidateCookieFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ValidateCookieFile(missing file) error = nil, want an error")
+	}
+}
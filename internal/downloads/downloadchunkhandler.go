@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/computer-technology-team/download-manager.git/internal/bandwidthlimit"
 	"github.com/computer-technology-team/download-manager.git/internal/state"
@@ -21,12 +23,19 @@ type DownloadChunkHandler struct {
 	currentPointer int64
 	pausedChan     *chan int
 	failedChan     chan error
+	finishedChan   chan *DownloadChunkHandler
 	wg             *sync.WaitGroup
 	singlePart     bool
+	mu             sync.Mutex
+
+	cancelAttempt context.CancelCauseFunc
+	lastProgress  time.Time
+
+	initialResponse *http.Response
 }
 
 func NewDownloadChunkHandler(cfg state.DownloadChunk,
-	pausedChan *chan int, failedChan chan error, wg *sync.WaitGroup) *DownloadChunkHandler {
+	pausedChan *chan int, failedChan chan error, finishedChan chan *DownloadChunkHandler, wg *sync.WaitGroup) *DownloadChunkHandler {
 	downChunk := DownloadChunkHandler{
 		mainDownloadID: cfg.DownloadID,
 		chunckID:       cfg.ID,
@@ -36,97 +45,312 @@ func NewDownloadChunkHandler(cfg state.DownloadChunk,
 		singlePart:     cfg.SinglePart,
 		wg:             wg,
 		failedChan:     failedChan,
+		finishedChan:   finishedChan,
 		pausedChan:     pausedChan,
 	}
 	return &downChunk
 }
 
-func (chunkHandler *DownloadChunkHandler) Start(ctx context.Context, url string, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter) {
+func (chunkHandler *DownloadChunkHandler) Start(ctx context.Context, client *http.Client, url, ifRange string, headers http.Header, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter, timeouts Timeouts) {
 	chunkHandler.wg.Add(1)
-	go chunkHandler.start(ctx, url, limiter, syncWriter)
+	go chunkHandler.start(ctx, client, url, ifRange, headers, limiter, syncWriter, timeouts)
 }
 
-func (chunkHandler *DownloadChunkHandler) start(ctx context.Context, url string, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter) {
+func (chunkHandler *DownloadChunkHandler) start(ctx context.Context, client *http.Client, url, ifRange string, headers http.Header, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter, timeouts Timeouts) {
 	defer chunkHandler.wg.Done()
 
-	writer := io.NewOffsetWriter(syncWriter, chunkHandler.currentPointer)
+	chunkHandler.download(ctx, client, url, ifRange, headers, limiter, syncWriter, timeouts)
 
-	resp, err := chunkHandler.sendRequest(ctx, url, chunkHandler.currentPointer, chunkHandler.rangeEnd)
-	if err != nil {
-		slog.Error("error sending request", "error", err)
+	if !chunkHandler.singlePart && chunkHandler.isDone() {
+		select {
+		case chunkHandler.finishedChan <- chunkHandler:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (chunkHandler *DownloadChunkHandler) download(ctx context.Context, client *http.Client, url, ifRange string, headers http.Header, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter, timeouts Timeouts) {
+	retries := 0
+	for {
+		pointer := chunkHandler.snapshot().CurrentPointer
 
-		chunkHandler.failedChan <- err
+		err := chunkHandler.attempt(ctx, client, url, ifRange, headers, limiter, syncWriter, timeouts)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
 
-		return
+		if chunkHandler.snapshot().CurrentPointer != pointer {
+			retries = 0
+		}
+
+		if !isChunkRetryable(err) || retries >= maxChunkRetries {
+			slog.Error("chunk download failed", "chunkID", chunkHandler.chunckID, "retries", retries, "error", err)
+			chunkHandler.fail(ctx, err)
+			return
+		}
+
+		delay := chunkRetryDelay(err, retries)
+		retries++
+		slog.Warn("chunk request failed, retrying", "chunkID", chunkHandler.chunckID,
+			"currentPointer", chunkHandler.snapshot().CurrentPointer, "retry", retries, "delay", delay, "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (chunkHandler *DownloadChunkHandler) attempt(ctx context.Context, client *http.Client, url, ifRange string, headers http.Header, limiter *bandwidthlimit.Limiter, syncWriter *SynchronizedFileWriter, timeouts Timeouts) error {
+	if chunkHandler.restartSinglePart() {
+		if err := syncWriter.Truncate(chunkHandler.rangeStart); err != nil {
+			return fmt.Errorf("could not truncate file to restart single part download: %w", err)
+		}
+	}
+
+	if chunkHandler.isDone() {
+		return nil
+	}
+
+	attemptCtx, cancel := chunkHandler.beginAttempt(ctx)
+	defer chunkHandler.endAttempt()
+
+	chunk := chunkHandler.snapshot()
+
+	writer := io.NewOffsetWriter(syncWriter, chunk.CurrentPointer)
+
+	resp := chunkHandler.initialResponse
+	chunkHandler.initialResponse = nil
+
+	if resp == nil {
+		var err error
+		resp, err = chunkHandler.sendRequest(attemptCtx, client, url, ifRange, headers, chunk.CurrentPointer, chunk.RangeEnd)
+		if err != nil {
+			return attemptError(attemptCtx, err)
+		}
 	}
 	defer resp.Body.Close()
 
-	reader := bandwidthlimit.NewLimitedReader(ctx, resp.Body, limiter)
+	stop := context.AfterFunc(attemptCtx, func() { resp.Body.Close() })
+	defer stop()
+
+	reader := bandwidthlimit.NewLimitedReader(attemptCtx,
+		newIdleTimeoutReader(resp.Body, timeouts.IdleRead, cancel), limiter)
 
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		case <-*chunkHandler.pausedChan:
-			return
+			return nil
 		default:
-			n, err := io.CopyN(writer, reader, 1<<14)
-			chunkHandler.currentPointer += int64(n)
+			n, err := io.CopyN(writer, reader, chunkHandler.nextReadSize())
+			chunkHandler.advance(n)
 			if err != nil {
 				if errors.Is(err, io.EOF) {
-					break
+					if chunkHandler.isStreaming() {
+						chunkHandler.finishStreaming()
+						return nil
+					}
+					if remaining := chunkHandler.getRemaining(); remaining > 0 {
+						slog.Error("response ended before chunk was complete", "chunkID", chunkHandler.chunckID, "remaining", remaining)
+						return &NetworkError{Err: io.ErrUnexpectedEOF}
+					}
+					return nil
 				}
 
-				if errors.Is(err, context.Canceled) {
-					return
+				if ctx.Err() != nil {
+					return nil
 				}
 
-				slog.Error("error reading from response", "error", err)
-				chunkHandler.failedChan <- err
-				return
+				return attemptError(attemptCtx, err)
 			}
 
-			if chunkHandler.currentPointer >= chunkHandler.rangeEnd {
-				return
+			if chunkHandler.isDone() {
+				return nil
 			}
 		}
 
 	}
 }
 
-func (chunkHandler *DownloadChunkHandler) sendRequest(ctx context.Context, requestURL string, rangeStart, rangeEnd int64) (*http.Response, error) {
+func attemptError(attemptCtx context.Context, err error) error {
+	if cause := context.Cause(attemptCtx); cause != nil && errors.Is(cause, ErrChunkStalled) {
+		return &NetworkError{Err: cause}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &NetworkError{Err: fmt.Errorf("%w: %w", ErrChunkStalled, err)}
+	}
+
+	if ErrorKindOf(err) == ErrorKindUnknown {
+		return &NetworkError{Err: err}
+	}
+	return err
+}
+
+func (chunkHandler *DownloadChunkHandler) beginAttempt(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+
+	chunkHandler.mu.Lock()
+	chunkHandler.cancelAttempt = cancel
+	chunkHandler.lastProgress = time.Now()
+	chunkHandler.mu.Unlock()
+
+	return attemptCtx, cancel
+}
+
+func (chunkHandler *DownloadChunkHandler) endAttempt() {
+	chunkHandler.mu.Lock()
+	cancel := chunkHandler.cancelAttempt
+	chunkHandler.cancelAttempt = nil
+	chunkHandler.mu.Unlock()
+
+	if cancel != nil {
+		cancel(nil)
+	}
+}
+
+func (chunkHandler *DownloadChunkHandler) restartIfStalled(now time.Time, stallTimeout time.Duration) bool {
+	chunkHandler.mu.Lock()
+	defer chunkHandler.mu.Unlock()
+
+	if chunkHandler.cancelAttempt == nil || now.Sub(chunkHandler.lastProgress) < stallTimeout {
+		return false
+	}
+
+	chunkHandler.cancelAttempt(fmt.Errorf("%w: no progress for %s", ErrChunkStalled, stallTimeout))
+	chunkHandler.cancelAttempt = nil
+	return true
+}
+
+func (chunkHandler *DownloadChunkHandler) fail(ctx context.Context, err error) {
+	select {
+	case chunkHandler.failedChan <- err:
+	case <-ctx.Done():
+	}
+}
+
+func (chunkHandler *DownloadChunkHandler) sendRequest(ctx context.Context, client *http.Client, requestURL, ifRange string, headers http.Header, rangeStart, rangeEnd int64) (*http.Response, error) {
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	applyHeaders(req, headers)
 
 	if !chunkHandler.singlePart {
 
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", rangeStart, rangeEnd-1))
-	}
-
-	transport := &http.Transport{
-		DisableCompression: true,
-	}
-
-	client := &http.Client{
-		Transport: transport,
+		if ifRange != "" {
+			req.Header.Set("If-Range", ifRange)
+		}
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, &NetworkError{Err: fmt.Errorf("request failed: %w", err)}
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		resp.Body.Close()
-		return nil, fmt.Errorf("server returned non-success status: %s", resp.Status)
+		return nil, newHTTPStatusError(resp)
+	}
+
+	if !chunkHandler.singlePart && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, &IntegrityError{Err: fmt.Errorf("%w: server answered range request with %s", ErrResourceChanged, resp.Status)}
 	}
 
 	return resp, nil
 }
 
+func (chunkHandler *DownloadChunkHandler) snapshot() state.DownloadChunk {
+	chunkHandler.mu.Lock()
+	defer chunkHandler.mu.Unlock()
+
+	return state.DownloadChunk{
+		ID:             chunkHandler.chunckID,
+		RangeStart:     chunkHandler.rangeStart,
+		RangeEnd:       chunkHandler.rangeEnd,
+		CurrentPointer: chunkHandler.currentPointer,
+		DownloadID:     chunkHandler.mainDownloadID,
+		SinglePart:     chunkHandler.singlePart,
+	}
+}
+
+func (chunkHandler *DownloadChunkHandler) restartSinglePart() bool {
+	chunkHandler.mu.Lock()
+	defer chunkHandler.mu.Unlock()
+
+	if !chunkHandler.singlePart || chunkHandler.currentPointer == chunkHandler.rangeStart {
+		return false
+	}
+	chunkHandler.currentPointer = chunkHandler.rangeStart
+	return true
+}
+
+func (chunkHandler *DownloadChunkHandler) nextReadSize() int64 {
+	chunkHandler.mu.Lock()
+	defer chunkHandler.mu.Unlock()
+
+	if chunkHandler.rangeEnd == unknownSize {
+		return maxReadSize
+	}
+	return min(maxReadSize, chunkHandler.rangeEnd-chunkHandler.currentPointer)
+}
+
+func (chunkHandler *DownloadChunkHandler) advance(n int64) {
+	chunkHandler.mu.Lock()
+	chunkHandler.currentPointer += n
+	if n > 0 {
+		chunkHandler.lastProgress = time.Now()
+	}
+	chunkHandler.mu.Unlock()
+}
+
+func (chunkHandler *DownloadChunkHandler) finishStreaming() {
+	chunkHandler.mu.Lock()
+	chunkHandler.rangeEnd = chunkHandler.currentPointer
+	chunkHandler.mu.Unlock()
+}
+
+func (chunkHandler *DownloadChunkHandler) splitTail(minSize int64) (int64, int64, bool) {
+	chunkHandler.mu.Lock()
+	defer chunkHandler.mu.Unlock()
+
+	remaining := chunkHandler.rangeEnd - chunkHandler.currentPointer
+	if chunkHandler.singlePart || chunkHandler.rangeEnd == unknownSize || remaining < 2*minSize {
+		return 0, 0, false
+	}
+
+	tailStart, tailEnd := chunkHandler.currentPointer+remaining/2, chunkHandler.rangeEnd
+	chunkHandler.rangeEnd = tailStart
+	return tailStart, tailEnd, true
+}
+
 func (DownloadHandler *DownloadChunkHandler) getRemaining() int64 {
+	DownloadHandler.mu.Lock()
+	defer DownloadHandler.mu.Unlock()
 	return DownloadHandler.rangeEnd - DownloadHandler.currentPointer
 }
+
+func (DownloadHandler *DownloadChunkHandler) getDownloaded() int64 {
+	DownloadHandler.mu.Lock()
+	defer DownloadHandler.mu.Unlock()
+	return DownloadHandler.currentPointer - DownloadHandler.rangeStart
+}
+
+func (DownloadHandler *DownloadChunkHandler) isStreaming() bool {
+	DownloadHandler.mu.Lock()
+	defer DownloadHandler.mu.Unlock()
+	return DownloadHandler.rangeEnd == unknownSize
+}
+
+func (DownloadHandler *DownloadChunkHandler) isDone() bool {
+	DownloadHandler.mu.Lock()
+	defer DownloadHandler.mu.Unlock()
+	return DownloadHandler.rangeEnd != unknownSize && DownloadHandler.rangeEnd-DownloadHandler.currentPointer <= 0
+}
@@ -2,6 +2,7 @@ package downloads
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"sync"
 
@@ -9,7 +10,7 @@ import (
 	"github.com/computer-technology-team/download-manager.git/internal/state"
 )
 
-func NewDownloadHandler(downloadConfig state.Download, downloadChuncks []state.DownloadChunk, limiter *bandwidthlimit.Limiter) (DownloadHandler, error) {
+func NewDownloadHandler(downloadConfig state.Download, downloadChuncks []state.DownloadChunk, connections int64, limiter *bandwidthlimit.Limiter, client *http.Client, headers http.Header, conflict ConflictPolicy, partFiles PartFiles, timeouts Timeouts) (DownloadHandler, error) {
 
 	pausedChan := make(chan int, 1)
 
@@ -18,8 +19,10 @@ func NewDownloadHandler(downloadConfig state.Download, downloadChuncks []state.D
 		queueID:       downloadConfig.QueueID,
 		url:           downloadConfig.Url,
 		savePath:      downloadConfig.SavePath,
+		partPath:      partFiles.Path(downloadConfig.ID, downloadConfig.SavePath),
 		state:         DownloadState(downloadConfig.State),
 		limiter:       limiter,
+		connections:   max(connections, 1),
 		chunkHandlers: nil,
 		progress:      0,
 		progressRate:  0,
@@ -27,38 +30,55 @@ func NewDownloadHandler(downloadConfig state.Download, downloadChuncks []state.D
 		pausedChan:    nil,
 		ctx:           nil,
 		ctxCancel:     nil,
-		failedChannel: make(chan error, numberOfChuncks),
+		client:        client,
+		headers:       headers,
+		timeouts:      timeouts,
+		failedChannel: make(chan error, connections),
 		wg:            sync.WaitGroup{},
+
+		finishedChannel: make(chan *DownloadChunkHandler),
 	}
 
 	defDow.pausedChan = &pausedChan
+	defDow.validators = validatorsFromDownload(downloadConfig)
+
+	if downloadConfig.Checksum.Valid {
+		checksum, err := ParseChecksum(downloadConfig.Checksum.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum for download %d: %w", downloadConfig.ID, err)
+		}
+		defDow.checksum = &checksum
+	}
 
-	if len(downloadChuncks) == numberOfChuncks {
-		chunkhandlersList := make([]*DownloadChunkHandler, numberOfChuncks)
+	if len(downloadChuncks) > 0 {
+		chunkhandlersList := make([]*DownloadChunkHandler, len(downloadChuncks))
 
 		for i, chunk := range downloadChuncks {
 
-			handler := NewDownloadChunkHandler(chunk, defDow.pausedChan, defDow.failedChannel, &defDow.wg)
+			handler := NewDownloadChunkHandler(chunk, defDow.pausedChan, defDow.failedChannel, defDow.finishedChannel, &defDow.wg)
 
 			chunkhandlersList[i] = handler
 		}
 		defDow.chunkHandlers = chunkhandlersList
-	} else if len(downloadChuncks) == 1 && downloadChuncks[0].SinglePart {
-		defDow.chunkHandlers = []*DownloadChunkHandler{
-			NewDownloadChunkHandler(downloadChuncks[0], defDow.pausedChan, defDow.failedChannel, &defDow.wg),
-		}
 	} else {
 
 		savePath := downloadConfig.SavePath
 
 		if _, err := os.Stat(savePath); err == nil {
-			return nil, fmt.Errorf("file already exists at %s", savePath)
+			switch conflict {
+			case ConflictOverwrite, ConflictResume:
+				defDow.conflict = conflict
+			default:
+				return nil, &DiskError{Err: fmt.Errorf("%w at %s", ErrFileExists, savePath)}
+			}
 		} else if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("error checking file at %s: %w", savePath, err)
+		} else if conflict == ConflictResume {
+			if _, err := os.Stat(defDow.partPath); err == nil {
+				defDow.conflict = conflict
+			}
 		}
 	}
 
-	defDow.writer = NewSynchronizedFileWriter(downloadConfig.SavePath)
-
 	return &defDow, nil
 }
@@ -22,28 +22,53 @@ import (
 const progressUpdatePeriod int = 1
 
 const movingAverageScale float64 = .75 
-const numberOfChuncks = 10
+
+const (
+	DefaultConnections int64 = 10
+	MaxConnections     int64 = 32
+)
+
+const maxReadSize int64 = 1 << 14
+const minSplitSize int64 = 1 << 16
 
 type defaultDownloader struct {
 	id            int64
 	queueID       int64
 	url           string
 	savePath      string
+	partPath      string
 	state         DownloadState
 	limiter       *bandwidthlimit.Limiter
+	connections   int64
 	chunkHandlers []*DownloadChunkHandler
 	progress      int64
 	progressRate  float64
 	size          int64
+	expectedSize  int64
 	pausedChan    *chan int
 	ctx           context.Context
 	ctxCancel     context.CancelFunc
 	writer        *SynchronizedFileWriter
 	wg            sync.WaitGroup
+	reporter      sync.WaitGroup
+	pauseOnce     sync.Once
+	completeOnce  sync.Once
+	checksum      *Checksum
+	validators    resourceValidators
+	contentType   string
+	client        *http.Client
+	headers       http.Header
+	timeouts      Timeouts
+	conflict      ConflictPolicy
 	failedChannel chan error
+
+	finishedChannel chan *DownloadChunkHandler
+	mu              sync.Mutex
 }
 
 func (d *defaultDownloader) keepTrackOfProgress() {
+	defer d.reporter.Done()
+
 	d.reportProgress()
 	for {
 		select {
@@ -52,6 +77,24 @@ func (d *defaultDownloader) keepTrackOfProgress() {
 			return
 		case <-time.After(time.Second * time.Duration(progressUpdatePeriod)):
 			d.reportProgress()
+			d.restartStalledChunks()
+		}
+	}
+}
+
+func (d *defaultDownloader) restartStalledChunks() {
+	if d.timeouts.Stall <= 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, handler := range d.chunkHandlers {
+		if handler.restartIfStalled(now, d.timeouts.Stall) {
+			slog.Warn("chunk made no progress, restarting it", "downloadID", d.id, "chunkID", handler.chunckID,
+				"stallTimeout", d.timeouts.Stall)
 		}
 	}
 }
@@ -61,61 +104,168 @@ func (d *defaultDownloader) reportProgress() {
 	newRate := float64(currentProgress-d.progress) / float64(progressUpdatePeriod)
 	d.progressRate = d.progressRate*(1-movingAverageScale) + newRate*movingAverageScale
 	d.progress = currentProgress
-	if d.progress == d.size {
-		d.state = StateCompleted
+	if d.allChunksDone() {
+		d.size = d.progress
+		d.completeOnce.Do(func() {
+			d.ctxCancel()
+			go d.complete()
+		})
+	} else {
 		events.GetEventChannel() <- events.Event{
-			EventType: events.DownloadCompleted,
+			EventType: events.DownloadProgressed,
 			Payload:   d.status(),
 		}
-		d.ctxCancel()
-	} else {
+	}
+
+}
+
+func (d *defaultDownloader) complete() {
+	d.wg.Wait()
+	d.reporter.Wait()
+
+	if err := d.writer.Sync(); err != nil {
+		d.writer.Close()
+		d.completionFailed(fmt.Errorf("could not flush %s to disk: %w", d.partPath, err))
+		return
+	}
+	d.writer.Close()
+
+	if err := verifySize(d.partPath, d.expectedSize); err != nil {
+		d.completionFailed(err)
+		return
+	}
+
+	if d.checksum != nil {
+		d.state = StateVerifying
 		events.GetEventChannel() <- events.Event{
-			EventType: events.DownloadProgressed,
+			EventType: events.DownloadVerifying,
 			Payload:   d.status(),
 		}
+
+		if err := d.checksum.Verify(d.partPath); err != nil {
+			d.completionFailed(err)
+			return
+		}
+
+		slog.Info("download verified", "downloadID", d.id, "algorithm", d.checksum.Algorithm)
 	}
 
+	if err := moveFile(d.partPath, d.savePath); err != nil {
+		d.completionFailed(fmt.Errorf("could not move %s into place: %w", d.partPath, err))
+		return
+	}
+
+	d.state = StateCompleted
+	events.GetEventChannel() <- events.Event{
+		EventType: events.DownloadCompleted,
+		Payload:   d.status(),
+	}
+}
+
+func (d *defaultDownloader) completionFailed(err error) {
+	slog.Error("could not complete download", "downloadID", d.id, "path", d.savePath, "error", err)
+
+	d.state = StateFailed
+	events.GetEventChannel() <- events.Event{
+		EventType: events.DownloadFailed,
+		Payload:   d.failedEvent(err),
+	}
 }
 
 func (d *defaultDownloader) getTotalProgress() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	total := int64(0)
 	for _, handler := range d.chunkHandlers {
-		total += handler.getRemaining()
+		total += handler.getDownloaded()
 	}
-	return d.size - total
+	return total
+}
+
+func (d *defaultDownloader) allChunksDone() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, handler := range d.chunkHandlers {
+		if !handler.isDone() {
+			return false
+		}
+	}
+	return true
 }
 
 func (d *defaultDownloader) Start() error {
 	d.ctx, d.ctxCancel = context.WithCancel(context.Background())
 
-	req, err := http.NewRequest("HEAD", d.url, nil)
+	probed, err := d.probe(d.ctx)
 	if err != nil {
-		return err
+		slog.Error("could not probe download", "downloadID", d.id, "url", d.url, "error", err)
+		d.fail(err)
+		return nil
+	}
+
+	if d.chunkHandlers == nil && d.conflict == ConflictResume {
+		if err := d.claimExistingFile(); err != nil {
+			if probed.firstResponse != nil {
+				probed.firstResponse.Body.Close()
+			}
+			slog.Error("could not move existing file to resume it", "downloadID", d.id, "path", d.savePath, "error", err)
+			d.fail(err)
+			return nil
+		}
 	}
 
-	httpClient := http.Client{Transport: &http.Transport{
-		DisableCompression: true,
-	}}
+	_, statErr := os.Stat(d.partPath)
+	partMissing := errors.Is(statErr, os.ErrNotExist)
 
-	resp, err := httpClient.Do(req)
+	writer, err := NewSynchronizedFileWriter(d.partPath)
 	if err != nil {
-		return fmt.Errorf("could not get headers from url %s: %w", d.url, err)
+		if probed.firstResponse != nil {
+			probed.firstResponse.Body.Close()
+		}
+		slog.Error("could not open download file", "downloadID", d.id, "path", d.partPath, "error", err)
+		d.fail(err)
+		return nil
 	}
 
-	d.writer = NewSynchronizedFileWriter(d.savePath)
+	d.mu.Lock()
+	d.writer = writer
+	d.mu.Unlock()
 
-	d.size, err = getContentSize(resp.Header)
-	if err != nil {
-		slog.Error("could not get content size", "error", err)
-		return fmt.Errorf("could not get content size from url %s: %w", d.url, err)
+	d.size = probed.size
+	d.expectedSize = probed.size
+	d.contentType = probed.contentType
+	if d.size == unknownSize {
+		slog.Info("server did not report content length, downloading in streaming mode", "downloadID", d.id, "url", d.url)
+	}
+
+	if err := d.validate(probed.validators, partMissing); err != nil {
+		if probed.firstResponse != nil {
+			probed.firstResponse.Body.Close()
+		}
+		slog.Error("could not validate download", "downloadID", d.id, "error", err)
+		d.fail(err)
+		return nil
+	}
+
+	var offset int64
+	if d.chunkHandlers == nil {
+		offset, err = d.existingFileOffset(probed)
+		if err != nil {
+			if probed.firstResponse != nil {
+				probed.firstResponse.Body.Close()
+			}
+			slog.Error("could not prepare download file", "downloadID", d.id, "path", d.partPath, "error", err)
+			d.fail(err)
+			return nil
+		}
 	}
 
 	var segmentsList [][]int64
-	var acceptsRanges bool
 
-	if doesAccpetRanges(resp) {
-		acceptsRanges = true
-		segmentsList = d.getChunkSegments()
+	if probed.acceptsRanges {
+		segmentsList = d.getChunkSegments(offset)
 	} else {
 		segmentsList = [][]int64{{0, d.size}}
 	}
@@ -123,6 +273,16 @@ func (d *defaultDownloader) Start() error {
 	if d.chunkHandlers == nil {
 		chunkhandlersList := make([]*DownloadChunkHandler, 0)
 
+		if offset > 0 {
+			chunkhandlersList = append(chunkhandlersList, NewDownloadChunkHandler(state.DownloadChunk{
+				ID:             uuid.NewString(),
+				RangeStart:     0,
+				RangeEnd:       offset,
+				CurrentPointer: offset,
+				DownloadID:     d.id,
+			}, d.pausedChan, d.failedChannel, d.finishedChannel, &d.wg))
+		}
+
 		for _, segment := range segmentsList {
 			l, r := segment[0], segment[1]
 
@@ -132,56 +292,145 @@ func (d *defaultDownloader) Start() error {
 				RangeEnd:       r,
 				CurrentPointer: l,
 				DownloadID:     d.id,
-				SinglePart:     !acceptsRanges,
-			}, d.pausedChan, d.failedChannel, &d.wg)
+				SinglePart:     !probed.acceptsRanges,
+			}, d.pausedChan, d.failedChannel, d.finishedChannel, &d.wg)
 
 			chunkhandlersList = append(chunkhandlersList, handler)
 		}
 
+		if len(chunkhandlersList) == 1 && chunkhandlersList[0].singlePart {
+			chunkhandlersList[0].initialResponse = probed.firstResponse
+			probed.firstResponse = nil
+		}
+
 		d.chunkHandlers = chunkhandlersList
 	}
 
+	if probed.firstResponse != nil {
+		probed.firstResponse.Body.Close()
+	}
+
 	for _, handler := range d.chunkHandlers {
-		handler.Start(d.ctx, d.url, d.limiter, d.writer)
+		handler.Start(d.ctx, d.client, d.url, d.validators.ifRange(), d.headers, d.limiter, d.writer, d.timeouts)
 	}
 
+	d.reporter.Add(1)
 	d.reportProgress()
 	go d.keepTrackOfProgress()
 	go d.listenForFailiure()
+	go d.listenForFinishedChunks()
 	return nil
 }
 
-func (d *defaultDownloader) getChunkSegments() [][]int64 {
+func (d *defaultDownloader) claimExistingFile() error {
+	if _, err := os.Stat(d.partPath); err == nil {
+		slog.Info("resuming from existing part file", "downloadID", d.id, "path", d.partPath)
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return &DiskError{Err: err}
+	}
+
+	return moveFile(d.savePath, d.partPath)
+}
+
+func (d *defaultDownloader) validate(probed resourceValidators, partMissing bool) error {
+	restarted := false
+
+	if d.chunkHandlers != nil && (partMissing || d.validators.differsFrom(probed)) {
+		if partMissing {
+			slog.Warn("unfinished download file is missing, restarting download from scratch", "downloadID", d.id, "path", d.partPath)
+		} else {
+			slog.Warn("remote resource changed, restarting download from scratch", "downloadID", d.id, "url", d.url)
+		}
+
+		if err := d.writer.Truncate(0); err != nil {
+			return fmt.Errorf("could not truncate %s to restart download: %w", d.partPath, err)
+		}
+		d.chunkHandlers = nil
+		restarted = true
+	}
+
+	d.validators = probed
+
+	events.GetEventChannel() <- events.Event{
+		EventType: events.DownloadProbed,
+		Payload: events.DownloadProbedEvent{
+			ID:            d.id,
+			ETag:          probed.etag,
+			LastModified:  probed.lastModified,
+			ContentLength: probed.contentLength,
+			ContentType:   d.contentType,
+			Restarted:     restarted,
+		},
+	}
+
+	return nil
+}
+
+func (d *defaultDownloader) existingFileOffset(probed probeResult) (int64, error) {
+	if d.conflict == ConflictResume {
+		existing, err := d.writer.Size()
+		if err != nil {
+			return 0, fmt.Errorf("could not read size of %s: %w", d.partPath, err)
+		}
+
+		if probed.acceptsRanges && existing <= d.size {
+			slog.Info("resuming download into existing file", "downloadID", d.id, "path", d.savePath, "existing", existing)
+			return existing, nil
+		}
 
-	chunkSize := int64(math.Ceil(float64(d.size) / numberOfChuncks))
+		slog.Warn("existing file can not be resumed, downloading from scratch", "downloadID", d.id, "path", d.savePath, "existing", existing, "size", d.size)
+	}
+
+	if err := d.writer.Truncate(0); err != nil {
+		return 0, fmt.Errorf("could not truncate %s: %w", d.partPath, err)
+	}
+	return 0, nil
+}
+
+func (d *defaultDownloader) getChunkSegments(offset int64) [][]int64 {
+
+	chunkSize := int64(math.Ceil(float64(d.size-offset) / float64(d.connections)))
 
 	segmentsList := make([][]int64, 0)
 
 	var i int64
-	for ; chunkSize*i < d.size; i++ {
-		segmentsList = append(segmentsList, []int64{i * chunkSize, min((i+1)*chunkSize, d.size)})
+	for ; offset+chunkSize*i < d.size; i++ {
+		segmentsList = append(segmentsList, []int64{offset + i*chunkSize, min(offset+(i+1)*chunkSize, d.size)})
 	}
 
 	return segmentsList
 }
 
+var errUnknownContentLength = errors.New("response does not have Content-Length")
+
 func getContentSize(header http.Header) (int64, error) {
 	contentLength := header.Get("Content-Length")
 	if contentLength == "" {
-		return 0, errors.New("response does not have Content-Length")
+		return 0, errUnknownContentLength
 	}
 	return strconv.ParseInt(contentLength, 10, 64)
 }
 
 func (d *defaultDownloader) Pause() error {
-	if d.ctxCancel != nil {
-		d.ctxCancel()
-		slog.Info("context canceled")
-	}
-	close(*d.pausedChan)
+	d.pauseOnce.Do(func() {
+		d.mu.Lock()
+		if d.ctxCancel != nil {
+			d.ctxCancel()
+			slog.Info("context canceled")
+		}
+		d.mu.Unlock()
+		close(*d.pausedChan)
 
-	d.wg.Wait()
-	d.writer.Close()
+		d.wg.Wait()
+		d.reporter.Wait()
+
+		d.mu.Lock()
+		if d.writer != nil {
+			d.writer.Close()
+		}
+		d.mu.Unlock()
+	})
 
 	return nil
 }
@@ -196,8 +445,8 @@ func (d *defaultDownloader) Cancel() error {
 		d.ctxCancel()
 	}
 
-	if d.savePath != "" {
-		if err := os.Remove(d.savePath); err != nil {
+	if d.partPath != "" {
+		if err := os.Remove(d.partPath); err != nil {
 			return fmt.Errorf("could not delete file: %w", err)
 		}
 	}
@@ -206,26 +455,31 @@ func (d *defaultDownloader) Cancel() error {
 }
 
 func (d *defaultDownloader) status() DownloadStatus {
+	progressPercentage := float64(-1)
+	if d.size > 0 {
+		progressPercentage = (float64(d.progress) / float64(d.size)) * 100
+	} else if d.size == 0 {
+		progressPercentage = 100
+	}
+
 	status := DownloadStatus{
 		ID:                 d.id,
-		ProgressPercentage: (float64(d.progress) / float64(d.size)) * 100,
+		URL:                d.url,
+		ProgressPercentage: progressPercentage,
 		Speed:              float64(d.progressRate),
+		TotalSize:          d.size,
+		Downloaded:         d.progress,
 		State:              d.state,
 		DownloadChuncks:    nil,
 	}
 
-	chunkList := make([]state.DownloadChunk, numberOfChuncks)
+	d.mu.Lock()
+	chunkList := make([]state.DownloadChunk, len(d.chunkHandlers))
 
 	for i, chunkHandler := range d.chunkHandlers {
-		downloadChunk := state.DownloadChunk{
-			ID:             chunkHandler.chunckID,
-			RangeStart:     chunkHandler.rangeStart,
-			RangeEnd:       chunkHandler.rangeEnd,
-			CurrentPointer: chunkHandler.currentPointer,
-			DownloadID:     d.id,
-		}
-		chunkList[i] = downloadChunk
+		chunkList[i] = chunkHandler.snapshot()
 	}
+	d.mu.Unlock()
 
 	status.DownloadChuncks = chunkList
 
@@ -235,17 +489,82 @@ func (d *defaultDownloader) status() DownloadStatus {
 func (d *defaultDownloader) listenForFailiure() {
 	select {
 	case err := <-d.failedChannel:
-		_ = d.Pause()
+		d.fail(err)
+		return
+	case <-d.ctx.Done():
+		return
+	}
 
-		events.GetEventChannel() <- events.Event{
-			EventType: events.DownloadFailed,
-			Payload:   events.DownloadFailedEvent{Error: err, ID: d.id, URL: d.url},
+}
+
+func (d *defaultDownloader) fail(err error) {
+	_ = d.Pause()
+
+	events.GetEventChannel() <- events.Event{
+		EventType: events.DownloadFailed,
+		Payload:   d.failedEvent(err),
+	}
+}
+
+func (d *defaultDownloader) failedEvent(err error) events.DownloadFailedEvent {
+	return events.DownloadFailedEvent{
+		ID:        d.id,
+		URL:       d.url,
+		Error:     err,
+		Kind:      string(ErrorKindOf(err)),
+		Permanent: IsPermanent(err),
+	}
+}
+
+func (d *defaultDownloader) listenForFinishedChunks() {
+	for {
+		select {
+		case <-d.finishedChannel:
+			d.splitLargestChunk()
+		case <-d.ctx.Done():
+			return
 		}
+	}
+}
+
+func (d *defaultDownloader) splitLargestChunk() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ctx.Err() != nil {
 		return
-	case <-d.ctx.Done():
+	}
+
+	var largest *DownloadChunkHandler
+	var largestRemaining int64
+	for _, handler := range d.chunkHandlers {
+		if remaining := handler.getRemaining(); remaining > largestRemaining {
+			largest, largestRemaining = handler, remaining
+		}
+	}
+
+	if largest == nil {
+		return
+	}
+
+	tailStart, tailEnd, ok := largest.splitTail(minSplitSize)
+	if !ok {
 		return
 	}
 
+	handler := NewDownloadChunkHandler(state.DownloadChunk{
+		ID:             uuid.NewString(),
+		RangeStart:     tailStart,
+		RangeEnd:       tailEnd,
+		CurrentPointer: tailStart,
+		DownloadID:     d.id,
+	}, d.pausedChan, d.failedChannel, d.finishedChannel, &d.wg)
+
+	d.chunkHandlers = append(d.chunkHandlers, handler)
+
+	slog.Debug("split chunk to keep idle connection busy", "downloadID", d.id, "chunkID", largest.chunckID, "newChunkID", handler.chunckID, "rangeStart", tailStart, "rangeEnd", tailEnd)
+
+	handler.Start(d.ctx, d.client, d.url, d.validators.ifRange(), d.headers, d.limiter, d.writer, d.timeouts)
 }
 
 func doesAccpetRanges(resp *http.Response) bool {
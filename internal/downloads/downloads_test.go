@@ -0,0 +1,112 @@
+package downloads
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/internal/bandwidthlimit"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func TestResumeKeepsExistingFileAfterFailure(t *testing.T) {
+	remote := bytes.Repeat([]byte("remote-"), 10000)
+	local := bytes.Repeat([]byte("L"), 4096)
+
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && failing.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(remote))
+	}))
+	defer server.Close()
+
+	savePath := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(savePath, local, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	download := state.Download{ID: 1, QueueID: 1, Url: server.URL, SavePath: savePath}
+	received := collectEvents(t)
+
+	start := func() {
+		t.Helper()
+		handler, err := NewDownloadHandler(download, nil, 2, bandwidthlimit.NewLimiter(nil), server.Client(), nil, ConflictResume, DefaultPartFiles(), DefaultTimeouts())
+		if err != nil {
+			t.Fatalf("NewDownloadHandler() error = %v", err)
+		}
+		if err := handler.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+	}
+
+	start()
+	waitForEvent(t, received, events.DownloadFailed)
+
+	if _, err := os.Stat(savePath); !os.IsNotExist(err) {
+		t.Fatalf("save path after failure: stat error = %v, want it moved to the part file", err)
+	}
+
+	failing.Store(false)
+	start()
+	waitForEvent(t, received, events.DownloadCompleted)
+
+	got, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]byte{}, local...), remote[len(local):]...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("resumed file differs from the existing bytes followed by the remote tail (got %d bytes, want %d)", len(got), len(want))
+	}
+}
+
+func collectEvents(t *testing.T) <-chan events.Event {
+	t.Helper()
+
+	source := events.GetEventChannel()
+	received := make(chan events.Event, 1024)
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-source:
+				select {
+				case received <- event:
+				default:
+				}
+			}
+		}
+	}()
+	return received
+}
+
+func waitForEvent(t *testing.T, received <-chan events.Event, eventType events.EventType) events.Event {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event := <-received:
+			if event.EventType == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for event %v", eventType)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package downloads
+
+import (
+	"crypto/tls"
+	"errors"
+)
+
+type ErrorKind string
+
+const (
+	ErrorKindHTTPStatus ErrorKind = "http_status"
+	ErrorKindNetwork    ErrorKind = "network"
+	ErrorKindDisk       ErrorKind = "disk"
+	ErrorKindIntegrity  ErrorKind = "integrity"
+	ErrorKindUnknown    ErrorKind = "unknown"
+)
+
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string { return e.Err.Error() }
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+type DiskError struct {
+	Err error
+}
+
+func (e *DiskError) Error() string { return e.Err.Error() }
+
+func (e *DiskError) Unwrap() error { return e.Err }
+
+type IntegrityError struct {
+	Err error
+}
+
+func (e *IntegrityError) Error() string { return e.Err.Error() }
+
+func (e *IntegrityError) Unwrap() error { return e.Err }
+
+type RemoteError struct {
+	Kind      ErrorKind
+	Permanent bool
+	Message   string
+}
+
+func (e *RemoteError) Error() string { return e.Message }
+
+func ErrorKindOf(err error) ErrorKind {
+	var (
+		remoteErr    *RemoteError
+		integrityErr *IntegrityError
+		diskErr      *DiskError
+		statusErr    *HTTPStatusError
+		networkErr   *NetworkError
+	)
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &remoteErr):
+		return remoteErr.Kind
+	case errors.As(err, &integrityErr):
+		return ErrorKindIntegrity
+	case errors.As(err, &diskErr):
+		return ErrorKindDisk
+	case errors.As(err, &statusErr):
+		return ErrorKindHTTPStatus
+	case errors.As(err, &networkErr):
+		return ErrorKindNetwork
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+func IsPermanent(err error) bool {
+	var remoteErr *RemoteError
+	if errors.As(err, &remoteErr) {
+		return remoteErr.Permanent
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return true
+	}
+
+	switch ErrorKindOf(err) {
+	case ErrorKindDisk:
+		return true
+	case ErrorKindIntegrity:
+		return errors.Is(err, ErrChecksumMismatch)
+	case ErrorKindHTTPStatus:
+		var statusErr *HTTPStatusError
+		errors.As(err, &statusErr)
+		return !statusErr.Retryable()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+package downloads
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "network", err: &NetworkError{Err: errors.New("reset")}, want: ErrorKindNetwork},
+		{name: "wrapped network", err: fmt.Errorf("probe: %w", &NetworkError{Err: errors.New("reset")}), want: ErrorKindNetwork},
+		{name: "http status", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: ErrorKindHTTPStatus},
+		{name: "disk", err: &DiskError{Err: errors.New("read-only file system")}, want: ErrorKindDisk},
+		{name: "integrity", err: &IntegrityError{Err: ErrSizeMismatch}, want: ErrorKindIntegrity},
+		{name: "integrity wins over disk", err: &IntegrityError{Err: &DiskError{Err: errors.New("short write")}}, want: ErrorKindIntegrity},
+		{name: "remote", err: &RemoteError{Kind: ErrorKindDisk, Message: "disk full"}, want: ErrorKindDisk},
+		{name: "unknown", err: errors.New("boom"), want: ErrorKindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorKindOf(tt.err); got != tt.want {
+				t.Errorf("ErrorKindOf(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network", err: &NetworkError{Err: errors.New("reset")}, want: false},
+		{name: "certificate", err: &NetworkError{Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, want: true},
+		{name: "not found", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: true},
+		{name: "unauthorized", err: &HTTPStatusError{StatusCode: http.StatusUnauthorized}, want: true},
+		{name: "request timeout", err: &HTTPStatusError{StatusCode: http.StatusRequestTimeout}, want: false},
+		{name: "too many requests", err: &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, want: false},
+		{name: "bad gateway", err: fmt.Errorf("chunk: %w", &HTTPStatusError{StatusCode: http.StatusBadGateway}), want: false},
+		{name: "disk", err: &DiskError{Err: errors.New("no space left on device")}, want: true},
+		{name: "checksum mismatch", err: &IntegrityError{Err: &ChecksumMismatchError{Algorithm: ChecksumSHA256}}, want: true},
+		{name: "size mismatch", err: &IntegrityError{Err: ErrSizeMismatch}, want: false},
+		{name: "resource changed", err: &IntegrityError{Err: ErrResourceChanged}, want: false},
+		{name: "remote permanent", err: &RemoteError{Kind: ErrorKindNetwork, Permanent: true}, want: true},
+		{name: "remote transient", err: &RemoteError{Kind: ErrorKindDisk}, want: false},
+		{name: "unknown", err: errors.New("boom"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanent(tt.err); got != tt.want {
+				t.Errorf("IsPermanent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultFileName = "download"
+	maxFileNameSize = 255
+)
+
+var preferredExtensions = map[string]string{
+	"application/gzip":         ".gz",
+	"application/json":         ".json",
+	"application/octet-stream": "",
+	"application/pdf":          ".pdf",
+	"application/x-tar":        ".tar",
+	"application/xml":          ".xml",
+	"application/zip":          ".zip",
+	"audio/mpeg":               ".mp3",
+	"image/jpeg":               ".jpg",
+	"image/png":                ".png",
+	"text/csv":                 ".csv",
+	"text/html":                ".html",
+	"text/plain":               ".txt",
+	"video/mp4":                ".mp4",
+}
+
+func ResolveFileName(ctx context.Context, client *http.Client, rawURL string, headers http.Header) (string, error) {
+	resp, err := fileNameRequest(ctx, client, http.MethodHead, rawURL, headers)
+	if err != nil || resp.StatusCode >= 400 {
+		resp, err = fileNameRequest(ctx, client, http.MethodGet, rawURL, headers)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		return "", newHTTPStatusError(resp)
+	}
+
+	return FileNameFromResponse(resp), nil
+}
+
+func fileNameRequest(ctx context.Context, client *http.Client, method, rawURL string, headers http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	applyHeaders(req, headers)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &NetworkError{Err: fmt.Errorf("%s request failed: %w", method, err)}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+func FileNameFromResponse(resp *http.Response) string {
+	name := SanitizeFileName(contentDispositionFileName(resp.Header.Get("Content-Disposition")))
+	if name == "" && resp.Request != nil {
+		name = FileNameFromURL(resp.Request.URL)
+	}
+
+	var ext string
+	if path.Ext(name) == "" {
+		ext = extensionForType(resp.Header.Get("Content-Type"))
+	}
+	if name == "" {
+		name = DefaultFileName
+	}
+
+	return SanitizeFileName(name + ext)
+}
+
+func FileNameFromURL(target *url.URL) string {
+	if target == nil {
+		return ""
+	}
+	return SanitizeFileName(path.Base(target.Path))
+}
+
+func contentDispositionFileName(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	if _, params, err := mime.ParseMediaType(header); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+
+	var plain, extended string
+	for _, part := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "filename*":
+			extended = decodeExtendedValue(strings.TrimSpace(value))
+		case "filename":
+			plain = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+
+	if extended != "" {
+		return extended
+	}
+	return plain
+}
+
+func decodeExtendedValue(value string) string {
+	charset, rest, ok := strings.Cut(value, "'")
+	if !ok {
+		return ""
+	}
+	_, encoded, ok := strings.Cut(rest, "'")
+	if !ok {
+		return ""
+	}
+
+	decoded, err := url.PathUnescape(encoded)
+	if err != nil {
+		return ""
+	}
+
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return decoded
+	case "iso-8859-1":
+		runes := make([]rune, 0, len(decoded))
+		for i := 0; i < len(decoded); i++ {
+			runes = append(runes, rune(decoded[i]))
+		}
+		return string(runes)
+	default:
+		return ""
+	}
+}
+
+func extensionForType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+
+	extensions, err := mime.ExtensionsByType(mediaType)
+	if err != nil || len(extensions) == 0 {
+		return ""
+	}
+	return extensions[0]
+}
+
+func SanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(name)
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"/|?*`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+	if name == "" || name == "_" {
+		return ""
+	}
+
+	if len(name) > maxFileNameSize {
+		ext := path.Ext(name)
+		if len(ext) > maxFileNameSize/2 {
+			ext = ""
+		}
+		base := name[:maxFileNameSize-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+
+	return name
+}
@@ -0,0 +1,160 @@
+package downloads
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestContentDispositionFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "empty", header: "", want: ""},
+		{name: "inline without name", header: "inline", want: ""},
+		{name: "quoted", header: `attachment; filename="report.pdf"`, want: "report.pdf"},
+		{name: "token", header: "attachment; filename=report.pdf", want: "report.pdf"},
+		{name: "quoted with spaces", header: `attachment; filename="annual report.pdf"`, want: "annual report.pdf"},
+		{name: "extended utf-8", header: "attachment; filename*=UTF-8''%E2%82%AC%20rates.txt", want: "€ rates.txt"},
+		{name: "extended lowercase charset", header: "attachment; filename*=utf-8''na%C3%AFve.txt", want: "naïve.txt"},
+		{name: "extended with language", header: "attachment; filename*=UTF-8'en'notes.txt", want: "notes.txt"},
+		{name: "extended preferred over plain", header: `attachment; filename="fallback.txt"; filename*=UTF-8''%E2%82%AC.txt`, want: "€.txt"},
+		{name: "extended before plain", header: `attachment; filename*=UTF-8''%E2%82%AC.txt; filename="fallback.txt"`, want: "€.txt"},
+		{name: "extended latin-1", header: "attachment; filename*=ISO-8859-1''caf%E9.txt", want: "café.txt"},
+		{name: "malformed falls back", header: `attachment; filename="broken.txt"; size`, want: "broken.txt"},
+		{name: "malformed extended", header: "attachment; filename*=UTF-8''%ZZ.txt", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentDispositionFileName(tt.header); got != tt.want {
+				t.Errorf("contentDispositionFileName(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	long := strings.Repeat("a", 300)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "file.zip", want: "file.zip"},
+		{name: "empty", input: "", want: ""},
+		{name: "unix traversal", input: "../../etc/passwd", want: "passwd"},
+		{name: "windows traversal", input: `..\..\windows\system.ini`, want: "system.ini"},
+		{name: "reserved characters", input: `a<b>c:d"e|f?g*h.txt`, want: "a_b_c_d_e_f_g_h.txt"},
+		{name: "control characters", input: "bad\x00na\nme.txt", want: "badname.txt"},
+		{name: "hidden file", input: ".bashrc", want: "bashrc"},
+		{name: "trailing dots and spaces", input: "  name.txt. . ", want: "name.txt"},
+		{name: "only dots", input: "...", want: ""},
+		{name: "only separator", input: "/", want: ""},
+		{name: "long keeps extension", input: long + ".tar.gz", want: long[:maxFileNameSize-len(".gz")] + ".gz"},
+		{name: "long multibyte", input: strings.Repeat("é", 200), want: strings.Repeat("é", maxFileNameSize/2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFileName(tt.input); got != tt.want {
+				t.Errorf("SanitizeFileName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileNameFromResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "content disposition wins",
+			url:    "https://example.com/download?id=1",
+			header: http.Header{"Content-Disposition": {`attachment; filename="data.csv"`}},
+			want:   "data.csv",
+		},
+		{
+			name:   "extended content disposition",
+			url:    "https://example.com/download",
+			header: http.Header{"Content-Disposition": {"attachment; filename*=UTF-8''%D9%81%D8%A7%DB%8C%D9%84.pdf"}},
+			want:   "فایل.pdf",
+		},
+		{
+			name:   "unsafe content disposition",
+			url:    "https://example.com/download",
+			header: http.Header{"Content-Disposition": {`attachment; filename="../../.profile"`}},
+			want:   "profile",
+		},
+		{
+			name: "url path",
+			url:  "https://example.com/files/archive.tar.gz?token=abc",
+			want: "archive.tar.gz",
+		},
+		{
+			name: "escaped url path",
+			url:  "https://example.com/files/my%20file.iso",
+			want: "my file.iso",
+		},
+		{
+			name:   "extension from content type",
+			url:    "https://example.com/export",
+			header: http.Header{"Content-Type": {"application/pdf"}},
+			want:   "export.pdf",
+		},
+		{
+			name:   "content type with parameters",
+			url:    "https://example.com/readme",
+			header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			want:   "readme.txt",
+		},
+		{
+			name:   "existing extension kept",
+			url:    "https://example.com/image.png",
+			header: http.Header{"Content-Type": {"image/jpeg"}},
+			want:   "image.png",
+		},
+		{
+			name:   "octet stream adds nothing",
+			url:    "https://example.com/blob",
+			header: http.Header{"Content-Type": {"application/octet-stream"}},
+			want:   "blob",
+		},
+		{
+			name: "root path",
+			url:  "https://example.com/",
+			want: DefaultFileName,
+		},
+		{
+			name:   "root path with content type",
+			url:    "https://example.com",
+			header: http.Header{"Content-Type": {"application/zip"}},
+			want:   DefaultFileName + ".zip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+
+			resp := &http.Response{Header: header, Request: &http.Request{URL: target}}
+			if got := FileNameFromResponse(resp); got != tt.want {
+				t.Errorf("FileNameFromResponse(%s) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package downloads
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const DefaultUserAgent = "download-manager"
+
+var ErrInvalidHeader = errors.New("invalid request header")
+
+var managedHeaders = []string{"Range", "If-Range", "Host", "Content-Length", "Transfer-Encoding", "Connection"}
+
+func ParseHeader(line string) (string, string, error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q is not in \"Name: Value\" form", ErrInvalidHeader, line)
+	}
+
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if err := ValidateHeader(name, value); err != nil {
+		return "", "", err
+	}
+
+	return http.CanonicalHeaderKey(name), value, nil
+}
+
+func ParseHeaders(lines []string) (map[string]string, error) {
+	headers := make(map[string]string, len(lines))
+	for _, line := range lines {
+		name, value, err := ParseHeader(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if previous, ok := headers[name]; ok {
+			separator := ", "
+			if name == "Cookie" {
+				separator = "; "
+			}
+			value = previous + separator + value
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+func ValidateHeader(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidHeader)
+	}
+
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r) {
+			return fmt.Errorf("%w: %q is not a valid header name", ErrInvalidHeader, name)
+		}
+	}
+
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("%w: value of %s contains a line break", ErrInvalidHeader, name)
+	}
+
+	for _, managed := range managedHeaders {
+		if strings.EqualFold(name, managed) {
+			return fmt.Errorf("%w: %s is set by the downloader", ErrInvalidHeader, managed)
+		}
+	}
+
+	return nil
+}
+
+func RequestHeaders(rows []state.DownloadHeader) http.Header {
+	headers := make(http.Header, len(rows))
+	for _, row := range rows {
+		headers.Add(row.Name, row.Value)
+	}
+	return headers
+}
+
+func applyHeaders(req *http.Request, headers http.Header) {
+	for name, values := range headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	}
+}
@@ -0,0 +1,177 @@
+package downloads
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type TransportConfig struct {
+	Timeouts            Timeouts
+	KeepAlive           time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableHTTP2        bool
+	TLS                 TLSOptions
+	Proxy               ProxyConfig
+	CookieFile          string
+}
+
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeouts:            DefaultTimeouts(),
+		KeepAlive:           30 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: int(MaxConnections),
+		IdleConnTimeout:     90 * time.Second,
+		TLS:                 TLSOptions{MinVersion: tls.VersionTLS12},
+	}
+}
+
+func (c TransportConfig) Validate() error {
+	if err := c.Timeouts.Validate(); err != nil {
+		return err
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 {
+		return errors.New("connection pool sizes can not be negative")
+	}
+	if c.KeepAlive < 0 || c.IdleConnTimeout < 0 {
+		return errors.New("keep-alive and idle connection timeouts can not be negative")
+	}
+	if err := c.Proxy.Validate(); err != nil {
+		return err
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+	return ValidateCookieFile(c.CookieFile)
+}
+
+type ClientOptions struct {
+	Host               string
+	Proxy              ProxyConfig
+	CookieFile         string
+	InsecureSkipVerify bool
+}
+
+type HTTPClientFactory interface {
+	Client(options ClientOptions) (*http.Client, error)
+}
+
+type HTTPClientFactoryFunc func(options ClientOptions) (*http.Client, error)
+
+func (f HTTPClientFactoryFunc) Client(options ClientOptions) (*http.Client, error) {
+	return f(options)
+}
+
+type sharedHTTPClientFactory struct {
+	config  TransportConfig
+	mu      sync.Mutex
+	tls     *tlsSettings
+	clients map[ClientOptions]*http.Client
+	jars    map[string]*CookieJar
+}
+
+func NewHTTPClientFactory(config TransportConfig) HTTPClientFactory {
+	return &sharedHTTPClientFactory{
+		config:  config,
+		clients: make(map[ClientOptions]*http.Client),
+		jars:    make(map[string]*CookieJar),
+	}
+}
+
+func (f *sharedHTTPClientFactory) Client(options ClientOptions) (*http.Client, error) {
+	options.Proxy = f.config.Proxy.Override(options.Proxy)
+	if options.CookieFile == "" {
+		options.CookieFile = f.config.CookieFile
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.tls == nil {
+		settings, err := f.config.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		f.tls = settings
+	}
+	options.Host = f.tls.certificateHost(options.Host)
+
+	jar, err := f.cookieJar(options.CookieFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if client, ok := f.clients[options]; ok {
+		return client, nil
+	}
+
+	proxy, err := options.Proxy.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	if options.InsecureSkipVerify {
+		slog.Warn("TLS certificate verification is DISABLED, connections can be intercepted", "proxy", options.Proxy.Redacted())
+	}
+
+	transport := newTransport(f.config, f.tls.clientConfig(options.Host, options.InsecureSkipVerify))
+	transport.Proxy = proxy
+
+	client := &http.Client{Transport: transport}
+	if jar != nil {
+		client.Jar = jar
+	}
+	f.clients[options] = client
+	return client, nil
+}
+
+func (f *sharedHTTPClientFactory) cookieJar(path string) (*CookieJar, error) {
+	if path == "" || path == NoCookieFile {
+		return nil, nil
+	}
+
+	if jar, ok := f.jars[path]; ok {
+		return jar, jar.Refresh()
+	}
+
+	jar, err := LoadCookieJar(path)
+	if err != nil {
+		return nil, err
+	}
+	f.jars[path] = jar
+	return jar, nil
+}
+
+func newTransport(config TransportConfig, tlsConfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   config.Timeouts.Connect,
+		KeepAlive: config.KeepAlive,
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.Timeouts.Connect,
+		ResponseHeaderTimeout: config.Timeouts.ResponseHeader,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		DisableCompression:    true,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !config.DisableHTTP2,
+	}
+
+	if config.DisableHTTP2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport
+}
@@ -0,0 +1,108 @@
+package downloads
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func DefaultNetrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+func NetrcCredentials(path, host string) (*Credentials, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not open netrc file: %w", err)
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for _, field := range fields {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			if field == "macdef" {
+				inMacro = true
+				break
+			}
+			tokens = append(tokens, field)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read netrc file: %w", err)
+	}
+
+	var match, fallback *Credentials
+	var current *Credentials
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+				if match == nil && strings.EqualFold(tokens[i], host) {
+					match = &Credentials{Host: host, Scheme: AuthBasic}
+					current = match
+				}
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &Credentials{Host: host, Scheme: AuthBasic, OnChallenge: true}
+				current = fallback
+			}
+		case "login", "password", "account":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			switch tokens[i-1] {
+			case "login":
+				current.Username = tokens[i]
+			case "password":
+				current.Secret = tokens[i]
+			}
+		}
+	}
+
+	credentials := match
+	if credentials == nil {
+		credentials = fallback
+	}
+	if credentials == nil || credentials.Username == "" {
+		return nil, nil
+	}
+	return credentials, nil
+}
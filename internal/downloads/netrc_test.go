@@ -0,0 +1,51 @@
+package downloads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testNetrc = `# credentials
+machine files.example.com login alice password s3cret
+machine other.example.com
+  login bob
+  password hunter2
+macdef init
+  cd /pub
+
+default login anonymous password guest
+`
+
+func TestNetrcCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(path, []byte(testNetrc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host string
+		want *Credentials
+	}{
+		{host: "files.example.com", want: &Credentials{Host: "files.example.com", Scheme: AuthBasic, Username: "alice", Secret: "s3cret"}},
+		{host: "FILES.example.com", want: &Credentials{Host: "FILES.example.com", Scheme: AuthBasic, Username: "alice", Secret: "s3cret"}},
+		{host: "other.example.com", want: &Credentials{Host: "other.example.com", Scheme: AuthBasic, Username: "bob", Secret: "hunter2"}},
+		{host: "unknown.example.com", want: &Credentials{Host: "unknown.example.com", Scheme: AuthBasic, Username: "anonymous", Secret: "guest", OnChallenge: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, err := NetrcCredentials(path, tt.host)
+			if err != nil {
+				t.Fatalf("NetrcCredentials(%q) error = %v", tt.host, err)
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("NetrcCredentials(%q) = %+v, want %+v", tt.host, got, tt.want)
+			}
+		})
+	}
+
+	if got, err := NetrcCredentials(filepath.Join(t.TempDir(), "missing"), "files.example.com"); got != nil || err != nil {
+		t.Errorf("NetrcCredentials(missing file) = %+v, %v, want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,132 @@
+package downloads
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const DefaultPartSuffix = ".part"
+
+var (
+	ErrInvalidPartFiles = errors.New("invalid part file configuration")
+	ErrSizeMismatch     = errors.New("size mismatch")
+)
+
+type PartFiles struct {
+	Suffix string
+	Dir    string
+}
+
+func DefaultPartFiles() PartFiles {
+	return PartFiles{Suffix: DefaultPartSuffix}
+}
+
+func (p PartFiles) Validate() error {
+	if p.Suffix == "" && p.Dir == "" {
+		return fmt.Errorf("%w: a part file suffix or a temporary directory is required", ErrInvalidPartFiles)
+	}
+	if strings.ContainsAny(p.Suffix, `/\`) {
+		return fmt.Errorf("%w: suffix %q can not contain path separators", ErrInvalidPartFiles, p.Suffix)
+	}
+	if p.Dir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(p.Dir)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPartFiles, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrInvalidPartFiles, p.Dir)
+	}
+	return nil
+}
+
+func (p PartFiles) Path(id int64, savePath string) string {
+	if p.Dir == "" {
+		return savePath + p.Suffix
+	}
+	return filepath.Join(p.Dir, fmt.Sprintf("%d-%s%s", id, filepath.Base(savePath), p.Suffix))
+}
+
+func verifySize(path string, expected int64) error {
+	if expected == unknownSize {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return &DiskError{Err: err}
+	}
+	if info.Size() != expected {
+		return &IntegrityError{Err: fmt.Errorf("%w: expected %d bytes, got %d", ErrSizeMismatch, expected, info.Size())}
+	}
+	return nil
+}
+
+func moveFile(from, to string) error {
+	err := os.Rename(from, to)
+	if errors.Is(err, syscall.EXDEV) {
+		err = copyAndRename(from, to)
+	}
+	if err != nil {
+		return &DiskError{Err: err}
+	}
+
+	syncDir(filepath.Dir(to))
+	return nil
+}
+
+func copyAndRename(from, to string) error {
+	temp, err := os.CreateTemp(filepath.Dir(to), "."+filepath.Base(to)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	source, err := os.Open(from)
+	if err != nil {
+		temp.Close()
+		return err
+	}
+	defer source.Close()
+
+	if _, err := io.Copy(temp, source); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), to); err != nil {
+		return err
+	}
+
+	return os.Remove(from)
+}
+
+func syncDir(dir string) {
+	handle, err := os.Open(dir)
+	if err != nil {
+		slog.Warn("could not open directory to sync it", "dir", dir, "error", err)
+		return
+	}
+	defer handle.Close()
+
+	if err := handle.Sync(); err != nil {
+		slog.Warn("could not sync directory", "dir", dir, "error", err)
+	}
+}
@@ -0,0 +1,148 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type probeResult struct {
+	size          int64
+	acceptsRanges bool
+	validators    resourceValidators
+	contentType   string
+	firstResponse *http.Response
+}
+
+func (d *defaultDownloader) probe(ctx context.Context) (probeResult, error) {
+	result, headErr := d.probeHead(ctx)
+	if headErr == nil && result.size != unknownSize {
+		return result, nil
+	}
+
+	if headErr != nil {
+		slog.Warn("HEAD probe failed, falling back to ranged GET", "downloadID", d.id, "url", d.url, "error", headErr)
+	} else {
+		slog.Info("HEAD probe did not report content length, probing with ranged GET", "downloadID", d.id, "url", d.url)
+	}
+
+	getResult, getErr := d.probeGet(ctx)
+	if getErr != nil {
+		if headErr == nil {
+			return result, nil
+		}
+		return probeResult{}, fmt.Errorf("could not probe url %s: %w", d.url, getErr)
+	}
+
+	return getResult, nil
+}
+
+func (d *defaultDownloader) probeHead(ctx context.Context) (probeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, d.url, nil)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("failed to create HEAD request: %w", err)
+	}
+	applyHeaders(req, d.headers)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return probeResult{}, &NetworkError{Err: fmt.Errorf("HEAD request failed: %w", err)}
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return probeResult{}, newHTTPStatusError(resp)
+	}
+
+	size, err := getContentSize(resp.Header)
+	if errors.Is(err, errUnknownContentLength) {
+		size = unknownSize
+	} else if err != nil {
+		return probeResult{}, fmt.Errorf("invalid Content-Length in HEAD response: %w", err)
+	}
+
+	return probeResult{
+		size:          size,
+		acceptsRanges: size != unknownSize && doesAccpetRanges(resp),
+		validators:    validatorsFromResponse(resp, size),
+		contentType:   resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+func (d *defaultDownloader) probeGet(ctx context.Context) (probeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("failed to create probe request: %w", err)
+	}
+	applyHeaders(req, d.headers)
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return probeResult{}, &NetworkError{Err: fmt.Errorf("probe request failed: %w", err)}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		size, err := parseContentRangeSize(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return probeResult{}, err
+		}
+
+		return probeResult{
+			size:          size,
+			acceptsRanges: size != unknownSize && resp.StatusCode == http.StatusPartialContent,
+			validators:    validatorsFromResponse(resp, size),
+			contentType:   resp.Header.Get("Content-Type"),
+		}, nil
+	case http.StatusOK:
+		size, err := getContentSize(resp.Header)
+		if errors.Is(err, errUnknownContentLength) {
+			size = unknownSize
+		} else if err != nil {
+			resp.Body.Close()
+			return probeResult{}, fmt.Errorf("invalid Content-Length in probe response: %w", err)
+		}
+
+		return probeResult{
+			size:          size,
+			validators:    validatorsFromResponse(resp, size),
+			contentType:   resp.Header.Get("Content-Type"),
+			firstResponse: resp,
+		}, nil
+	default:
+		resp.Body.Close()
+		return probeResult{}, newHTTPStatusError(resp)
+	}
+}
+
+func parseContentRangeSize(contentRange string) (int64, error) {
+	unit, rest, ok := strings.Cut(contentRange, " ")
+	if !ok || unit != "bytes" {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+
+	_, total, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+
+	if total == "*" {
+		return unknownSize, nil
+	}
+
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size in Content-Range %q", contentRange)
+	}
+
+	return size, nil
+}
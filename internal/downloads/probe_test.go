@@ -0,0 +1,137 @@
+package downloads
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fixedModTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseContentRangeSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "bytes 0-0/1234", want: 1234},
+		{value: "bytes */1234", want: 1234},
+		{value: "bytes 0-0/*", want: unknownSize},
+		{value: "bytes 0-0/0", want: 0},
+		{value: "items 0-0/1234", wantErr: true},
+		{value: "bytes 0-0", wantErr: true},
+		{value: "bytes 0-0/-1", wantErr: true},
+		{value: "bytes 0-0/abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseContentRangeSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentRangeSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseContentRangeSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	const body = "0123456789"
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantSize   int64
+		wantRanges bool
+		wantFirst  bool
+		wantErr    bool
+	}{
+		{
+			name: "head with ranges",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Accept-Ranges", "bytes")
+				http.ServeContent(w, r, "file.bin", fixedModTime, strings.NewReader(body))
+			},
+			wantSize:   int64(len(body)),
+			wantRanges: true,
+		},
+		{
+			name: "head not allowed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				http.ServeContent(w, r, "file.bin", fixedModTime, strings.NewReader(body))
+			},
+			wantSize:   int64(len(body)),
+			wantRanges: true,
+		},
+		{
+			name: "head without length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.Header().Set("Transfer-Encoding", "chunked")
+					return
+				}
+				http.ServeContent(w, r, "file.bin", fixedModTime, strings.NewReader(body))
+			},
+			wantSize:   int64(len(body)),
+			wantRanges: true,
+		},
+		{
+			name: "ranges ignored by get",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.WriteHeader(http.StatusNotImplemented)
+					return
+				}
+				w.Write([]byte(body))
+			},
+			wantSize:  int64(len(body)),
+			wantFirst: true,
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			d := &defaultDownloader{url: server.URL, client: server.Client()}
+			got, err := d.probe(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.firstResponse != nil {
+				got.firstResponse.Body.Close()
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.size != tt.wantSize {
+				t.Errorf("probe() size = %d, want %d", got.size, tt.wantSize)
+			}
+			if got.acceptsRanges != tt.wantRanges {
+				t.Errorf("probe() acceptsRanges = %v, want %v", got.acceptsRanges, tt.wantRanges)
+			}
+			if (got.firstResponse != nil) != tt.wantFirst {
+				t.Errorf("probe() kept first response = %v, want %v", got.firstResponse != nil, tt.wantFirst)
+			}
+		})
+	}
+}
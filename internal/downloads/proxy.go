@@ -0,0 +1,180 @@
+package downloads
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DirectProxy = "direct"
+
+var ErrInvalidProxy = errors.New("invalid proxy")
+
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+type ProxyConfig struct {
+	URL     string
+	NoProxy string
+}
+
+func (p ProxyConfig) Validate() error {
+	_, err := p.parse()
+	return err
+}
+
+func (p ProxyConfig) Override(override ProxyConfig) ProxyConfig {
+	if override.URL != "" {
+		p.URL = override.URL
+	}
+	if override.NoProxy != "" {
+		p.NoProxy = override.NoProxy
+	}
+	return p
+}
+
+func (p ProxyConfig) Redacted() string {
+	proxyURL, err := p.parse()
+	if err != nil || proxyURL == nil {
+		return p.URL
+	}
+	return proxyURL.Redacted()
+}
+
+func (p ProxyConfig) parse() (*url.URL, error) {
+	if p.URL == "" || p.URL == DirectProxy {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(p.URL)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxy, err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q, use http, https, socks5 or socks5h", ErrInvalidProxy, proxyURL.Scheme)
+	}
+
+	if proxyURL.Hostname() == "" {
+		return nil, fmt.Errorf("%w: missing host in %q", ErrInvalidProxy, proxyURL.Redacted())
+	}
+
+	return proxyURL, nil
+}
+
+func (p ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	var proxy func(*http.Request) (*url.URL, error)
+
+	switch p.URL {
+	case "":
+		proxy = proxyFromEnvironment
+	case DirectProxy:
+		return nil, nil
+	default:
+		proxyURL, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	bypass := parseNoProxy(p.NoProxy)
+	if len(bypass) == 0 {
+		return proxy, nil
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL) {
+			return nil, nil
+		}
+		return proxy(req)
+	}, nil
+}
+
+type noProxyEntry struct {
+	domain  string
+	port    string
+	network *net.IPNet
+	ip      net.IP
+}
+
+type noProxyList []noProxyEntry
+
+func parseNoProxy(value string) noProxyList {
+	var list noProxyList
+
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		field = strings.ToLower(strings.TrimSpace(field))
+
+		if field == "*" {
+			return noProxyList{{domain: "*"}}
+		}
+
+		if _, network, err := net.ParseCIDR(field); err == nil {
+			list = append(list, noProxyEntry{network: network})
+			continue
+		}
+
+		host, port := field, ""
+		if h, p, err := net.SplitHostPort(field); err == nil {
+			host, port = h, p
+		}
+		host = strings.Trim(host, "[]")
+
+		if ip := net.ParseIP(host); ip != nil {
+			list = append(list, noProxyEntry{ip: ip, port: port})
+			continue
+		}
+
+		list = append(list, noProxyEntry{domain: strings.TrimPrefix(host, "."), port: port})
+	}
+
+	return list
+}
+
+func (l noProxyList) matches(target *url.URL) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = defaultPort(target.Scheme)
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range l {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+
+		switch {
+		case entry.domain == "*":
+			return true
+		case entry.network != nil:
+			if ip != nil && entry.network.Contains(ip) {
+				return true
+			}
+		case entry.ip != nil:
+			if ip != nil && entry.ip.Equal(ip) {
+				return true
+			}
+		case host == entry.domain || strings.HasSuffix(host, "."+entry.domain):
+			return true
+		}
+	}
+
+	return false
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
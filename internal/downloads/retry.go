@@ -0,0 +1,80 @@
+package downloads
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxChunkRetries     = 5
+	chunkRetryBaseDelay = time.Second
+	chunkRetryMaxDelay  = 30 * time.Second
+	maxRetryAfter       = 5 * time.Minute
+)
+
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	return &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "server returned non-success status: " + e.Status
+}
+
+func (e *HTTPStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return min(date.Sub(now), maxRetryAfter)
+	}
+
+	return 0
+}
+
+func isChunkRetryable(err error) bool {
+	switch ErrorKindOf(err) {
+	case ErrorKindNetwork, ErrorKindHTTPStatus:
+		return !IsPermanent(err)
+	default:
+		return false
+	}
+}
+
+func chunkRetryDelay(err error, retry int) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter
+	}
+
+	delay := min(chunkRetryBaseDelay<<min(retry, 16), chunkRetryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,99 @@
+package downloads
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "120", want: 2 * time.Minute},
+		{value: " 5 ", want: 5 * time.Second},
+		{value: "0", want: 0},
+		{value: "-3", want: 0},
+		{value: "86400", want: maxRetryAfter},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{value: now.Add(time.Hour).Format(http.TimeFormat), want: maxRetryAfter},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkRetryDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		retry int
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "first retry", err: &NetworkError{Err: errors.New("reset")}, retry: 0, min: chunkRetryBaseDelay / 2, max: chunkRetryBaseDelay},
+		{name: "third retry", err: &NetworkError{Err: errors.New("reset")}, retry: 2, min: 2 * chunkRetryBaseDelay, max: 4 * chunkRetryBaseDelay},
+		{name: "capped", err: &NetworkError{Err: errors.New("reset")}, retry: 10, min: chunkRetryMaxDelay / 2, max: chunkRetryMaxDelay},
+		{name: "large retry count", err: &NetworkError{Err: errors.New("reset")}, retry: 100, min: chunkRetryMaxDelay / 2, max: chunkRetryMaxDelay},
+		{
+			name:  "retry after wins",
+			err:   fmt.Errorf("chunk: %w", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 42 * time.Second}),
+			retry: 0,
+			min:   42 * time.Second,
+			max:   42 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if got := chunkRetryDelay(tt.err, tt.retry); got < tt.min || got > tt.max {
+					t.Fatalf("chunkRetryDelay(retry %d) = %s, want between %s and %s", tt.retry, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsChunkRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection reset", err: &NetworkError{Err: errors.New("connection reset by peer")}, want: true},
+		{name: "stalled", err: &NetworkError{Err: ErrChunkStalled}, want: true},
+		{name: "untrusted certificate", err: &NetworkError{Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, want: false},
+		{name: "service unavailable", err: &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "too many requests", err: &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "request timeout", err: &HTTPStatusError{StatusCode: http.StatusRequestTimeout}, want: true},
+		{name: "not found", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "forbidden", err: &HTTPStatusError{StatusCode: http.StatusForbidden}, want: false},
+		{name: "disk full", err: &DiskError{Err: errors.New("no space left on device")}, want: false},
+		{name: "resource changed", err: &IntegrityError{Err: ErrResourceChanged}, want: false},
+		{name: "unknown", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isChunkRetryable(tt.err); got != tt.want {
+				t.Errorf("isChunkRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
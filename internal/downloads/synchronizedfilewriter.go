@@ -10,24 +10,55 @@ type SynchronizedFileWriter struct {
 	file  *os.File
 }
 
-func NewSynchronizedFileWriter(filePath string) *SynchronizedFileWriter {
+func NewSynchronizedFileWriter(filePath string) (*SynchronizedFileWriter, error) {
 	var file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		panic("failed to open file") 
+		return nil, &DiskError{Err: err}
 	}
 	return &SynchronizedFileWriter{
 		mutex: &sync.Mutex{},
 		file:  file,
-	}
+	}, nil
 }
 
 func (writer *SynchronizedFileWriter) WriteAt(buffer []byte, at int64) (int, error) {
 	writer.mutex.Lock()
 	n, err := writer.file.WriteAt(buffer, at)
 	writer.mutex.Unlock()
-	return n, err
+	if err != nil {
+		return n, &DiskError{Err: err}
+	}
+	return n, nil
+}
+
+func (writer *SynchronizedFileWriter) Sync() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	if err := writer.file.Sync(); err != nil {
+		return &DiskError{Err: err}
+	}
+	return nil
 }
 
 func (writer *SynchronizedFileWriter) Close() {
 	writer.file.Close()
 }
+
+func (writer *SynchronizedFileWriter) Size() (int64, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	info, err := writer.file.Stat()
+	if err != nil {
+		return 0, &DiskError{Err: err}
+	}
+	return info.Size(), nil
+}
+
+func (writer *SynchronizedFileWriter) Truncate(size int64) error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	if err := writer.file.Truncate(size); err != nil {
+		return &DiskError{Err: err}
+	}
+	return nil
+}
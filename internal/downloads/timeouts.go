@@ -0,0 +1,56 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrChunkStalled = errors.New("chunk connection stalled")
+
+type Timeouts struct {
+	Connect        time.Duration
+	ResponseHeader time.Duration
+	IdleRead       time.Duration
+	Stall          time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Connect:        15 * time.Second,
+		ResponseHeader: 30 * time.Second,
+		IdleRead:       30 * time.Second,
+		Stall:          60 * time.Second,
+	}
+}
+
+func (t Timeouts) Validate() error {
+	if t.Connect < 0 || t.ResponseHeader < 0 || t.IdleRead < 0 || t.Stall < 0 {
+		return errors.New("timeouts can not be negative")
+	}
+	return nil
+}
+
+type idleTimeoutReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	cancel  context.CancelCauseFunc
+}
+
+func newIdleTimeoutReader(reader io.Reader, timeout time.Duration, cancel context.CancelCauseFunc) io.Reader {
+	if timeout <= 0 {
+		return reader
+	}
+	return &idleTimeoutReader{reader: reader, timeout: timeout, cancel: cancel}
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	timer := time.AfterFunc(r.timeout, func() {
+		r.cancel(fmt.Errorf("%w: no data received for %s", ErrChunkStalled, r.timeout))
+	})
+	defer timer.Stop()
+
+	return r.reader.Read(p)
+}
@@ -0,0 +1,134 @@
+package downloads
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var ErrInvalidTLSConfig = errors.New("invalid TLS configuration")
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type ClientCertificate struct {
+	Host     string
+	CertFile string
+	KeyFile  string
+}
+
+func ParseClientCertificate(value string) (ClientCertificate, error) {
+	host, files, ok := strings.Cut(value, "=")
+	if !ok || host == "" || files == "" {
+		return ClientCertificate{}, fmt.Errorf("%w: client certificate %q must look like HOST=CERT[,KEY]", ErrInvalidTLSConfig, value)
+	}
+
+	certFile, keyFile, ok := strings.Cut(files, ",")
+	if !ok {
+		keyFile = certFile
+	}
+
+	return ClientCertificate{Host: strings.ToLower(host), CertFile: certFile, KeyFile: keyFile}, nil
+}
+
+func ParseTLSVersion(value string) (uint16, error) {
+	version, ok := tlsVersions[strings.TrimPrefix(value, "TLS")]
+	if !ok {
+		return 0, fmt.Errorf("%w: unsupported TLS version %q, use 1.0, 1.1, 1.2 or 1.3", ErrInvalidTLSConfig, value)
+	}
+	return version, nil
+}
+
+type TLSOptions struct {
+	CAFiles            []string
+	ClientCertificates []ClientCertificate
+	MinVersion         uint16
+}
+
+func (o TLSOptions) Validate() error {
+	_, err := o.load()
+	return err
+}
+
+type tlsSettings struct {
+	config       *tls.Config
+	certificates []hostCertificate
+}
+
+type hostCertificate struct {
+	host        string
+	certificate tls.Certificate
+}
+
+func (o TLSOptions) load() (*tlsSettings, error) {
+	config := &tls.Config{MinVersion: o.MinVersion}
+
+	if len(o.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, file := range o.CAFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%w: could not read CA file: %w", ErrInvalidTLSConfig, err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("%w: no PEM certificates found in %s", ErrInvalidTLSConfig, file)
+			}
+		}
+
+		config.RootCAs = pool
+	}
+
+	settings := &tlsSettings{config: config}
+	for _, clientCert := range o.ClientCertificates {
+		certificate, err := tls.LoadX509KeyPair(clientCert.CertFile, clientCert.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not load client certificate for %s: %w", ErrInvalidTLSConfig, clientCert.Host, err)
+		}
+		settings.certificates = append(settings.certificates, hostCertificate{host: clientCert.Host, certificate: certificate})
+	}
+
+	return settings, nil
+}
+
+func (s *tlsSettings) certificateHost(hostport string) string {
+	for _, certificate := range s.certificates {
+		if hostMatches(certificate.host, hostport) {
+			return certificate.host
+		}
+	}
+	return ""
+}
+
+func (s *tlsSettings) clientConfig(certificateHost string, insecure bool) *tls.Config {
+	config := s.config.Clone()
+
+	for _, certificate := range s.certificates {
+		if certificate.host == certificateHost {
+			config.Certificates = []tls.Certificate{certificate.certificate}
+			break
+		}
+	}
+	config.InsecureSkipVerify = insecure
+
+	return config
+}
+
+func hostMatches(pattern, hostport string) bool {
+	if _, _, err := net.SplitHostPort(pattern); err == nil {
+		return strings.EqualFold(pattern, hostport)
+	}
+	return strings.EqualFold(strings.Trim(pattern, "[]"), (&url.URL{Host: hostport}).Hostname())
+}
@@ -2,6 +2,8 @@ package downloads
 
 import "github.com/computer-technology-team/download-manager.git/internal/state"
 
+const unknownSize int64 = -1
+
 type DownloadState string
 
 const (
@@ -10,6 +12,9 @@ const (
 	StateCompleted  DownloadState = "COMPLETED"
 	StateFailed     DownloadState = "FAILED"
 	StatePending    DownloadState = "PENDING"
+	StateVerifying  DownloadState = "VERIFYING"
+
+	StateChecksumMismatch DownloadState = "CHECKSUM_MISMATCH"
 )
 
 type DownloadHandler interface {
@@ -23,6 +28,12 @@ type DownloadStatus struct {
 	URL                string
 	ProgressPercentage float64
 	Speed              float64
+	TotalSize          int64
+	Downloaded         int64
 	State              DownloadState
 	DownloadChuncks    []state.DownloadChunk
 }
+
+func (s DownloadStatus) SizeKnown() bool {
+	return s.TotalSize >= 0
+}
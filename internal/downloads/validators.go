@@ -0,0 +1,53 @@
+package downloads
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+var ErrResourceChanged = errors.New("remote resource changed since the download started")
+
+type resourceValidators struct {
+	etag          string
+	lastModified  string
+	contentLength int64
+	hasLength     bool
+}
+
+func validatorsFromDownload(download state.Download) resourceValidators {
+	return resourceValidators{
+		etag:          download.Etag.String,
+		lastModified:  download.LastModified.String,
+		contentLength: download.ContentLength.Int64,
+		hasLength:     download.ContentLength.Valid,
+	}
+}
+
+func validatorsFromResponse(resp *http.Response, size int64) resourceValidators {
+	return resourceValidators{
+		etag:          resp.Header.Get("ETag"),
+		lastModified:  resp.Header.Get("Last-Modified"),
+		contentLength: size,
+		hasLength:     size != unknownSize,
+	}
+}
+
+func (v resourceValidators) differsFrom(other resourceValidators) bool {
+	if v.etag != "" && other.etag != "" && v.etag != other.etag {
+		return true
+	}
+	if v.lastModified != "" && other.lastModified != "" && v.lastModified != other.lastModified {
+		return true
+	}
+	return v.hasLength && other.hasLength && v.contentLength != other.contentLength
+}
+
+func (v resourceValidators) ifRange() string {
+	if v.etag != "" && !strings.HasPrefix(v.etag, "W/") {
+		return v.etag
+	}
+	return v.lastModified
+}
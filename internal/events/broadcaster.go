@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+func (b *Broadcaster) Run(ctx context.Context, source <-chan Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-source:
+			if !ok {
+				return
+			}
+			b.publish(event)
+		}
+	}
+}
+
+func (b *Broadcaster) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			slog.Warn("dropping event for slow subscriber", "eventType", event.EventType)
+		}
+	}
+}
+
+func (b *Broadcaster) Subscribe() (<-chan Event, func()) {
+	subscriber := make(chan Event, chanSize)
+
+	b.mu.Lock()
+	b.subscribers[subscriber] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, subscriber)
+			b.mu.Unlock()
+		})
+	}
+
+	return subscriber, unsubscribe
+}
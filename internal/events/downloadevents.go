@@ -1,7 +1,18 @@
 package events
 
 type DownloadFailedEvent struct {
-	ID    int64
-	URL   string
-	Error error
+	ID        int64
+	URL       string
+	Error     error
+	Kind      string
+	Permanent bool
+}
+
+type DownloadProbedEvent struct {
+	ID            int64  `json:"id"`
+	ETag          string `json:"etag,omitempty"`
+	LastModified  string `json:"last_modified,omitempty"`
+	ContentLength int64  `json:"content_length"`
+	ContentType   string `json:"content_type,omitempty"`
+	Restarted     bool   `json:"restarted"`
 }
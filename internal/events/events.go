@@ -15,6 +15,8 @@ const (
 	QueueEdited
 	DownloadCreated
 	DownloadDeleted
+	DownloadVerifying
+	DownloadProbed
 )
 
 type Event struct {
@@ -37,3 +39,24 @@ func GetUIEventChannel() chan Event {
 	}
 	return uiEventChannel
 }
+
+var eventTypeNames = map[EventType]string{
+	DownloadFailed:       "download_failed",
+	DownloadProgressed:   "download_progressed",
+	DownloadCompleted:    "download_completed",
+	DownloadStateChanged: "download_state_changed",
+	QueueCreated:         "queue_created",
+	QueueDeleted:         "queue_deleted",
+	QueueEdited:          "queue_edited",
+	DownloadCreated:      "download_created",
+	DownloadDeleted:      "download_deleted",
+	DownloadVerifying:    "download_verifying",
+	DownloadProbed:       "download_probed",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
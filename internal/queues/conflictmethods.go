@@ -0,0 +1,169 @@
+package queues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func queueConflictPolicy(queue state.Queue) downloads.ConflictPolicy {
+	policy, err := downloads.ParseConflictPolicy(queue.ConflictPolicy)
+	if err != nil {
+		slog.Warn("invalid queue conflict policy, using default", "queueID", queue.ID, "error", err)
+		return downloads.DefaultConflictPolicy
+	}
+	return policy
+}
+
+func (q *queueManager) claimSavePath(ctx context.Context, queue state.Queue, savePath string) (string, error) {
+	existing, err := q.queries.GetDownloadBySavePath(ctx, savePath)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to look up download by save path", "path", savePath, "error", err)
+		return "", fmt.Errorf("failed to look up download by save path: %w", err)
+	}
+	hasDownload := err == nil
+
+	fileExists, err := pathExists(savePath)
+	if err != nil {
+		return "", err
+	}
+
+	if !hasDownload && !fileExists {
+		return savePath, nil
+	}
+
+	switch policy := queueConflictPolicy(queue); policy {
+	case downloads.ConflictSkip:
+		slog.Warn("save path is taken, skipping download", "path", savePath, "queueID", queue.ID)
+		return "", fmt.Errorf("%w: %s", downloads.ErrFileExists, savePath)
+	case downloads.ConflictOverwrite, downloads.ConflictResume:
+		if hasDownload {
+			if err := q.releaseSavePath(ctx, existing, policy); err != nil {
+				return "", err
+			}
+		}
+		slog.Info("reusing taken save path", "path", savePath, "policy", policy)
+		return savePath, nil
+	default:
+		return q.renameSavePath(ctx, savePath, 0)
+	}
+}
+
+func (q *queueManager) releaseSavePath(ctx context.Context, existing state.Download, policy downloads.ConflictPolicy) error {
+	q.mu.RLock()
+	_, running := q.inProgressHandlers[existing.ID]
+	q.mu.RUnlock()
+
+	switch downloads.DownloadState(existing.State) {
+	case downloads.StateCompleted, downloads.StateFailed, downloads.StateChecksumMismatch:
+	default:
+		running = true
+	}
+	if running {
+		slog.Error("save path is used by an active download", "path", existing.SavePath, "downloadID", existing.ID, "state", existing.State)
+		return fmt.Errorf("%w: %s belongs to download %d", ErrSavePathInUse, existing.SavePath, existing.ID)
+	}
+
+	if policy == downloads.ConflictResume {
+		if err := q.keepPartialData(existing); err != nil {
+			return err
+		}
+	}
+
+	slog.Warn("deleting finished download to reuse its save path", "path", existing.SavePath, "downloadID", existing.ID, "state", existing.State, "policy", policy)
+	return q.DeleteDownload(ctx, existing.ID)
+}
+
+func (q *queueManager) keepPartialData(existing state.Download) error {
+	partPath := q.partFiles.Path(existing.ID, existing.SavePath)
+
+	partExists, err := pathExists(partPath)
+	if err != nil || !partExists {
+		return err
+	}
+	fileExists, err := pathExists(existing.SavePath)
+	if err != nil || fileExists {
+		return err
+	}
+
+	if err := os.Rename(partPath, existing.SavePath); err != nil {
+		slog.Error("failed to keep partial data of replaced download", "path", partPath, "downloadID", existing.ID, "error", err)
+		return fmt.Errorf("failed to keep partial data of download %d: %w", existing.ID, err)
+	}
+	return nil
+}
+
+func (q *queueManager) renameSavePath(ctx context.Context, savePath string, ignoreID int64) (string, error) {
+	for n := 1; ; n++ {
+		candidate := downloads.RenamedSavePath(savePath, n)
+
+		existing, err := q.queries.GetDownloadBySavePath(ctx, candidate)
+		if err == nil && existing.ID != ignoreID {
+			continue
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to look up download by save path", "path", candidate, "error", err)
+			return "", fmt.Errorf("failed to look up download by save path: %w", err)
+		}
+
+		fileExists, err := pathExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !fileExists {
+			slog.Info("save path is taken, renamed download", "path", savePath, "renamed", candidate)
+			return candidate, nil
+		}
+	}
+}
+
+func (q *queueManager) resolveStartConflict(ctx context.Context, download state.Download) (state.Download, downloads.ConflictPolicy, error) {
+	queue, err := q.queries.GetQueue(ctx, download.QueueID)
+	if err != nil {
+		slog.Error("failed to get queue details", "queueID", download.QueueID, "error", err)
+		return download, "", fmt.Errorf("failed to get queue details: %w", err)
+	}
+	policy := queueConflictPolicy(queue)
+
+	fileExists, err := pathExists(download.SavePath)
+	if err != nil || !fileExists || policy != downloads.ConflictRename {
+		return download, policy, err
+	}
+
+	savePath, err := q.renameSavePath(ctx, download.SavePath, download.ID)
+	if err != nil {
+		return download, policy, err
+	}
+
+	if err := q.queries.SetDownloadSavePath(ctx, state.SetDownloadSavePathParams{SavePath: savePath, ID: download.ID}); err != nil {
+		slog.Error("failed to save renamed download path", "downloadID", download.ID, "error", err)
+		return download, policy, fmt.Errorf("failed to save renamed download path: %w", err)
+	}
+
+	download.SavePath = savePath
+	return download, policy, nil
+}
+
+func (q *queueManager) skipDownload(ctx context.Context, download state.Download, cause error) error {
+	slog.Warn("save path is taken, skipping download", "downloadID", download.ID, "error", cause)
+	return q.markDownloadFailed(ctx, download, cause)
+}
+
+func pathExists(filePath string) (bool, error) {
+	_, err := os.Stat(filePath)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, os.ErrNotExist):
+		return false, nil
+	default:
+		slog.Error("failed to check save path", "path", filePath, "error", err)
+		return false, fmt.Errorf("failed to check save path %s: %w", filePath, err)
+	}
+}
@@ -0,0 +1,133 @@
+package queues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func TestReleaseSavePath(t *testing.T) {
+	manager := newTestQueueManager(t)
+	ctx := context.Background()
+	directory := t.TempDir()
+
+	if err := manager.CreateQueue(ctx, state.CreateQueueParams{
+		Name:           "resume",
+		Directory:      directory,
+		RetryLimit:     3,
+		MaxConcurrent:  2,
+		ConflictPolicy: string(downloads.ConflictResume),
+	}); err != nil {
+		t.Fatalf("CreateQueue() error = %v", err)
+	}
+	queues, err := manager.ListQueue(ctx)
+	if err != nil || len(queues) != 1 {
+		t.Fatalf("ListQueue() = %v, %v, want one queue", queues, err)
+	}
+
+	server := stallingServer(t)
+	params := CreateDownloadParams{URL: server.URL + "/file.bin", FileName: "file.bin", QueueID: queues[0].ID}
+
+	first, err := manager.CreateDownload(ctx, params)
+	if err != nil {
+		t.Fatalf("CreateDownload() error = %v", err)
+	}
+
+	if _, err := manager.CreateDownload(ctx, params); !errors.Is(err, ErrSavePathInUse) {
+		t.Fatalf("CreateDownload() over an active download error = %v, want %v", err, ErrSavePathInUse)
+	}
+
+	if err := manager.PauseDownload(ctx, first); err != nil {
+		t.Fatalf("PauseDownload() error = %v", err)
+	}
+	if _, err := manager.CreateDownload(ctx, params); !errors.Is(err, ErrSavePathInUse) {
+		t.Fatalf("CreateDownload() over a paused download error = %v, want %v", err, ErrSavePathInUse)
+	}
+
+	if _, err := manager.queries.SetDownloadState(ctx, state.SetDownloadStateParams{ID: first, State: string(downloads.StateFailed)}); err != nil {
+		t.Fatalf("SetDownloadState() error = %v", err)
+	}
+	savePath := filepath.Join(directory, "file.bin")
+	partial := []byte("partial data")
+	if err := os.WriteFile(manager.partFiles.Path(first, savePath), partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := manager.CreateDownload(ctx, params)
+	if err != nil {
+		t.Fatalf("CreateDownload() over a failed download error = %v", err)
+	}
+	if err := manager.PauseDownload(ctx, second); err != nil {
+		t.Fatalf("PauseDownload() error = %v", err)
+	}
+
+	if _, err := manager.queries.GetDownload(ctx, first); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetDownload() of the replaced download error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	got, err := os.ReadFile(manager.partFiles.Path(second, savePath))
+	if errors.Is(err, os.ErrNotExist) {
+		got, err = os.ReadFile(savePath)
+	}
+	if err != nil {
+		t.Fatalf("reading kept data: %v", err)
+	}
+	if string(got) != string(partial) {
+		t.Errorf("kept data = %q, want %q", got, partial)
+	}
+}
+
+func TestCreateDownloadConcurrentSavePath(t *testing.T) {
+	manager := newTestQueueManager(t)
+	ctx := context.Background()
+	queue := createTestQueue(t, manager, state.CreateQueueParams{MaxConcurrent: 1})
+	server := stallingServer(t)
+
+	const adds = 8
+	ids := make(chan int64, adds)
+	errs := make(chan error, adds)
+
+	var wg sync.WaitGroup
+	for range adds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := manager.CreateDownload(ctx, CreateDownloadParams{
+				URL:      server.URL + "/file.bin",
+				FileName: "file.bin",
+				QueueID:  queue.ID,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("CreateDownload() error = %v", err)
+	}
+
+	savePaths := make(map[string]int64)
+	for id := range ids {
+		download, err := manager.queries.GetDownload(ctx, id)
+		if err != nil {
+			t.Fatalf("GetDownload() error = %v", err)
+		}
+		if other, ok := savePaths[download.SavePath]; ok {
+			t.Errorf("downloads %d and %d share save path %s", other, id, download.SavePath)
+		}
+		savePaths[download.SavePath] = id
+	}
+}
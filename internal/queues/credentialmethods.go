@@ -0,0 +1,126 @@
+package queues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func (q *queueManager) SetCredential(ctx context.Context, arg state.UpsertCredentialParams) error {
+	arg.Host = strings.ToLower(arg.Host)
+	arg.Scheme = strings.ToLower(arg.Scheme)
+
+	if err := downloads.CredentialsFromState(state.Credential{
+		Host:     arg.Host,
+		Scheme:   arg.Scheme,
+		Username: arg.Username,
+		Secret:   arg.Secret,
+	}).Validate(); err != nil {
+		slog.Error("invalid credentials", "host", arg.Host, "error", err)
+		return err
+	}
+
+	if _, err := q.queries.UpsertCredential(ctx, arg); err != nil {
+		slog.Error("failed to save credentials", "host", arg.Host, "error", err)
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	slog.Info("credentials saved", "host", arg.Host, "scheme", arg.Scheme)
+	return nil
+}
+
+func (q *queueManager) ListCredentials(ctx context.Context) ([]state.Credential, error) {
+	credentials, err := q.queries.ListCredentials(ctx)
+	if err != nil {
+		slog.Error("failed to list credentials", "error", err)
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+func (q *queueManager) DeleteCredential(ctx context.Context, host string) error {
+	if err := q.queries.DeleteCredential(ctx, strings.ToLower(host)); err != nil {
+		slog.Error("failed to delete credentials", "host", host, "error", err)
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+
+	slog.Info("credentials deleted", "host", host)
+	return nil
+}
+
+func (q *queueManager) downloadCredentials(ctx context.Context, rawURL string) (*downloads.Credentials, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse download URL: %w", err)
+	}
+
+	for _, host := range []string{target.Host, target.Hostname()} {
+		row, err := q.queries.GetCredentialByHost(ctx, strings.ToLower(host))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			slog.Error("failed to get credentials", "host", host, "error", err)
+			return nil, fmt.Errorf("failed to get credentials for %s: %w", host, err)
+		}
+
+		credentials := downloads.CredentialsFromState(row)
+		return &credentials, nil
+	}
+
+	credentials, err := downloads.NetrcCredentials(q.netrcPath, target.Hostname())
+	if err != nil {
+		slog.Warn("could not read netrc credentials", "path", q.netrcPath, "error", err)
+		return nil, nil
+	}
+
+	return credentials, nil
+}
+
+func (q *queueManager) urlCredentials(ctx context.Context, target *url.URL, replace bool) (*state.UpsertCredentialParams, error) {
+	if target.User == nil {
+		return nil, nil
+	}
+
+	password, _ := target.User.Password()
+	credentials := state.UpsertCredentialParams{
+		Host:     strings.ToLower(target.Host),
+		Scheme:   string(downloads.AuthBasic),
+		Username: target.User.Username(),
+		Secret:   password,
+	}
+
+	if err := downloads.CredentialsFromState(state.Credential{
+		Host:     credentials.Host,
+		Scheme:   credentials.Scheme,
+		Username: credentials.Username,
+		Secret:   credentials.Secret,
+	}).Validate(); err != nil {
+		slog.Error("invalid credentials in download URL", "host", credentials.Host, "error", err)
+		return nil, err
+	}
+
+	existing, err := q.queries.GetCredentialByHost(ctx, credentials.Host)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &credentials, nil
+	} else if err != nil {
+		slog.Error("failed to get credentials", "host", credentials.Host, "error", err)
+		return nil, fmt.Errorf("failed to get credentials for %s: %w", credentials.Host, err)
+	}
+
+	if existing.Scheme != credentials.Scheme && !replace {
+		slog.Warn("download URL credentials would replace credentials of another scheme", "host", credentials.Host,
+			"scheme", existing.Scheme)
+		return nil, fmt.Errorf("%w: %s uses %s authentication, replace it explicitly to store the credentials of the URL",
+			ErrCredentialConflict, credentials.Host, existing.Scheme)
+	}
+
+	return &credentials, nil
+}
@@ -2,11 +2,16 @@ package queues
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"slices"
 
 	"github.com/computer-technology-team/download-manager.git/internal/bandwidthlimit"
 	"github.com/computer-technology-team/download-manager.git/internal/downloads"
@@ -15,21 +20,22 @@ import (
 )
 
 func (q *queueManager) PauseDownload(ctx context.Context, id int64) error {
-	if err := q.setDownloadState(ctx, id, string(downloads.StatePaused)); err != nil {
-		return err
-	}
-
 	currentDownload, err := q.queries.GetDownload(ctx, id)
 	if err != nil {
 		slog.Error("failed to get download details", "downloadID", id, "error", err)
 		return fmt.Errorf("failed to get download details: %w", err)
 	}
 
-	if currentDownload.State != string(downloads.StateInProgress) {
+	if currentDownload.State != string(downloads.StateInProgress) &&
+		currentDownload.State != string(downloads.StatePending) {
 		slog.Error("invalid state", "current_download_state", currentDownload.State)
 		return errors.New("can not pause download that is not in progress")
 	}
 
+	if err := q.setDownloadState(ctx, id, string(downloads.StatePaused)); err != nil {
+		return err
+	}
+
 	q.mu.Lock()
 	handler, ok := q.inProgressHandlers[id]
 	if ok {
@@ -51,12 +57,22 @@ func (q *queueManager) PauseDownload(ctx context.Context, id int64) error {
 }
 
 func (q *queueManager) ResumeDownload(ctx context.Context, id int64) error {
+	if err := q.ensureNotRunning(id); err != nil {
+		return err
+	}
+
 	downloadConfig, err := q.queries.GetDownload(ctx, id)
 	if err != nil {
 		slog.Error("failed to get download configuration", "downloadID", id, "error", err)
 		return fmt.Errorf("failed to get download configuration: %w", err)
 	}
 
+	if downloadConfig.State == string(downloads.StateChecksumMismatch) {
+		if err := q.discardDownloadedData(ctx, downloadConfig); err != nil {
+			return err
+		}
+	}
+
 	downloadChunks, err := q.queries.GetDownloadChunksByDownloadID(ctx, id)
 	if err != nil {
 		slog.Error("failed to get download chunks", "downloadID", id, "error", err)
@@ -81,7 +97,56 @@ func (q *queueManager) ResumeDownload(ctx context.Context, id int64) error {
 	}
 	q.mu.Unlock()
 
-	handler, err := downloads.NewDownloadHandler(downloadConfig, downloadChunks, limiter)
+	open, err := q.isQueueOpen(ctx, downloadConfig.QueueID)
+	if err != nil {
+		return err
+	}
+
+	if !open {
+		if err := q.setDownloadState(ctx, id, string(downloads.StatePending)); err != nil {
+			return err
+		}
+
+		slog.Info("queue is outside its download window, download will start when it opens", "downloadID", id)
+		return nil
+	}
+
+	if q.engineDisabled {
+		if err := q.setDownloadState(ctx, id, string(downloads.StatePending)); err != nil {
+			return err
+		}
+
+		slog.Info("download engine is not running, download queued as pending", "downloadID", id)
+		return nil
+	}
+
+	connections, err := q.downloadConnections(ctx, downloadConfig)
+	if err != nil {
+		return err
+	}
+
+	client, err := q.downloadClient(ctx, downloadConfig)
+	if err != nil {
+		return err
+	}
+
+	headers, err := q.downloadHeaders(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var conflictPolicy downloads.ConflictPolicy
+	if len(downloadChunks) == 0 {
+		downloadConfig, conflictPolicy, err = q.resolveStartConflict(ctx, downloadConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	handler, err := downloads.NewDownloadHandler(downloadConfig, downloadChunks, connections, limiter, client, headers, conflictPolicy, q.partFiles, q.timeouts)
+	if errors.Is(err, downloads.ErrFileExists) {
+		return q.skipDownload(ctx, downloadConfig, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -90,9 +155,22 @@ func (q *queueManager) ResumeDownload(ctx context.Context, id int64) error {
 		return err
 	}
 
-	q.mu.RLock()
+	if err := q.queries.SetDownloadStarted(ctx, state.SetDownloadStartedParams{
+		StartedAt: sql.NullTime{Time: q.clock(), Valid: true},
+		ID:        id,
+	}); err != nil {
+		slog.Error("failed to save download start time", "downloadID", id, "error", err)
+		return fmt.Errorf("failed to save download start time: %w", err)
+	}
+
+	q.mu.Lock()
+	if _, running := q.inProgressHandlers[id]; running {
+		q.mu.Unlock()
+		slog.Warn("download was started concurrently", "downloadID", id)
+		return fmt.Errorf("%w: %d", ErrDownloadInProgress, id)
+	}
 	q.inProgressHandlers[id] = handler
-	q.mu.RUnlock()
+	q.mu.Unlock()
 
 	if err := handler.Start(); err != nil {
 		slog.Error("failed to start download handler", "downloadID", id, "error", err)
@@ -103,7 +181,23 @@ func (q *queueManager) ResumeDownload(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (q *queueManager) ensureNotRunning(id int64) error {
+	q.mu.RLock()
+	_, running := q.inProgressHandlers[id]
+	q.mu.RUnlock()
+
+	if running {
+		slog.Warn("download is already in progress", "downloadID", id)
+		return fmt.Errorf("%w: %d", ErrDownloadInProgress, id)
+	}
+	return nil
+}
+
 func (q *queueManager) RetryDownload(ctx context.Context, id int64) error {
+	if err := q.ensureNotRunning(id); err != nil {
+		return err
+	}
+
 	_, err := q.queries.SetDownloadRetry(ctx, state.SetDownloadRetryParams{Retries: 0, ID: id})
 	if err != nil {
 		slog.Error("failed to set download retry count", "downloadID", id, "error", err)
@@ -114,66 +208,139 @@ func (q *queueManager) RetryDownload(ctx context.Context, id int64) error {
 	return q.ResumeDownload(ctx, id)
 }
 
-func (q *queueManager) CreateDownload(ctx context.Context, downloadURL, fileName string, queueID int64) error {
+func (q *queueManager) CreateDownload(ctx context.Context, params CreateDownloadParams) (int64, error) {
+	downloadURL, fileName, queueID := params.URL, params.FileName, params.QueueID
+
 	parsedURL, err := url.Parse(downloadURL)
 	if err != nil {
 		slog.Error("failed to parse download URL", "url", downloadURL, "error", err)
-		return fmt.Errorf("failed to parse download URL: %w", err)
+		return 0, fmt.Errorf("failed to parse download URL: %w", err)
 	}
 
-	if fileName == "" {
-		lastPathSegment := path.Base(parsedURL.Path)
+	var checksum sql.NullString
+	if params.Checksum != "" {
+		parsedChecksum, err := downloads.ParseChecksum(params.Checksum)
+		if err != nil {
+			slog.Error("invalid checksum", "checksum", params.Checksum, "error", err)
+			return 0, fmt.Errorf("%w: %w", ErrInvalidChecksum, err)
+		}
+		checksum = sql.NullString{String: parsedChecksum.String(), Valid: true}
+	}
 
-		if lastPathSegment == "" || lastPathSegment == "." || lastPathSegment == "/" {
-			slog.Error("empty file name in URL", "url", downloadURL)
-			return ErrEmptyFileName
+	var connections sql.NullInt64
+	if params.Connections != 0 {
+		if err := validateConnections(params.Connections); err != nil {
+			slog.Error("invalid connection count", "connections", params.Connections, "error", err)
+			return 0, err
 		}
+		connections = sql.NullInt64{Int64: params.Connections, Valid: true}
+	}
 
-		fileName = lastPathSegment
+	headerNames := slices.Sorted(maps.Keys(params.Headers))
+	for _, name := range headerNames {
+		if err := downloads.ValidateHeader(name, params.Headers[name]); err != nil {
+			slog.Error("invalid request header", "name", name, "error", err)
+			return 0, err
+		}
 	}
 
 	queue, err := q.queries.GetQueue(ctx, queueID)
 	if err != nil {
 		slog.Error("failed to get queue from database", "queueID", queueID, "error", err)
-		return fmt.Errorf("failed to get queue: %w", err)
+		return 0, fmt.Errorf("failed to get queue: %w", err)
 	}
 
-	createDownloadParams := state.CreateDownloadParams{
-		QueueID:  queueID,
-		Url:      downloadURL,
-		SavePath: path.Join(queue.Directory, fileName),
-		State:    string(downloads.StatePending),
-		Retries:  0,
+	urlCredentials, err := q.urlCredentials(ctx, parsedURL, params.ReplaceCredentials)
+	if err != nil {
+		return 0, err
+	}
+	if parsedURL.User != nil {
+		withoutUser := *parsedURL
+		withoutUser.User = nil
+		downloadURL = withoutUser.String()
+	}
+
+	if fileName == "" {
+		fileName = q.resolveFileName(ctx, queueID, parsedURL, params.Headers)
+	} else {
+		fileName = downloads.SanitizeFileName(fileName)
+	}
+	if fileName == "" {
+		slog.Error("empty file name for download", "url", downloadURL, "fileName", params.FileName)
+		return 0, ErrEmptyFileName
 	}
 
-	createDownloadParams.State = string(downloads.StateInProgress)
+	q.savePathMu.Lock()
+	savePath, err := q.claimSavePath(ctx, queue, path.Join(queue.Directory, fileName))
+	if err != nil {
+		q.savePathMu.Unlock()
+		return 0, err
+	}
+
+	createDownloadParams := state.CreateDownloadParams{
+		QueueID:     queueID,
+		Url:         downloadURL,
+		SavePath:    savePath,
+		State:       string(downloads.StatePending),
+		Retries:     0,
+		Checksum:    checksum,
+		Connections: connections,
+		CreatedAt:   sql.NullTime{Time: q.clock(), Valid: true},
+	}
 
 	download, err := q.queries.CreateDownload(ctx, createDownloadParams)
+	q.savePathMu.Unlock()
 	if err != nil {
 		slog.Error("failed to create download", "params", createDownloadParams, "error", err)
-		return fmt.Errorf("failed to create download: %w", err)
+		return 0, fmt.Errorf("failed to create download: %w", err)
+	}
+
+	for _, name := range headerNames {
+		if err := q.queries.CreateDownloadHeader(ctx, state.CreateDownloadHeaderParams{
+			DownloadID: download.ID,
+			Name:       http.CanonicalHeaderKey(name),
+			Value:      params.Headers[name],
+		}); err != nil {
+			slog.Error("failed to save download header", "downloadID", download.ID, "name", name, "error", err)
+			if err := q.queries.DeleteDownload(ctx, download.ID); err != nil {
+				slog.Error("failed to remove download after header error", "downloadID", download.ID, "error", err)
+			}
+			return 0, fmt.Errorf("failed to save download header %s: %w", name, err)
+		}
+	}
+
+	if urlCredentials != nil {
+		if err := q.SetCredential(ctx, *urlCredentials); err != nil {
+			if err := q.queries.DeleteDownload(ctx, download.ID); err != nil {
+				slog.Error("failed to remove download after credentials error", "downloadID", download.ID, "error", err)
+			}
+			return 0, err
+		}
 	}
 
 	events.GetUIEventChannel() <- events.Event{
 		EventType: events.DownloadCreated,
 		Payload: state.ListDownloadsWithQueueNameRow{
-			ID:        download.ID,
-			QueueID:   download.QueueID,
-			Url:       download.Url,
-			SavePath:  download.SavePath,
-			State:     download.State,
-			Retries:   download.Retries,
-			QueueName: queue.Name,
+			ID:          download.ID,
+			QueueID:     download.QueueID,
+			Url:         download.Url,
+			SavePath:    download.SavePath,
+			State:       download.State,
+			Retries:     download.Retries,
+			Checksum:    download.Checksum,
+			Connections: download.Connections,
+			CreatedAt:   download.CreatedAt,
+			QueueName:   queue.Name,
 		},
 	}
 
 	slog.Info("download created successfully", "downloadID", download.ID)
 
 	if err := q.startNextDownloadIfPossible(ctx, queueID); err != nil {
-		return err
+		return download.ID, err
 	}
 
-	return nil
+	return download.ID, nil
 }
 
 func (q *queueManager) DeleteDownload(ctx context.Context, id int64) error {
@@ -193,13 +360,23 @@ func (q *queueManager) DeleteDownload(ctx context.Context, id int64) error {
 		slog.Error("failed to get download details", "downloadID", id, "error", err)
 		return fmt.Errorf("failed to get download details: %w", err)
 	}
-	queueID := currentDownload.QueueID 
+	queueID := currentDownload.QueueID
 
 	if err := q.queries.DeleteDownload(ctx, id); err != nil {
 		slog.Error("failed to delete download", "downloadID", id, "error", err)
 		return fmt.Errorf("failed to delete download: %w", err)
 	}
 
+	partPath := q.partFiles.Path(id, currentDownload.SavePath)
+	if err := os.Remove(partPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove unfinished download file", "path", partPath, "error", err)
+	}
+
+	events.GetUIEventChannel() <- events.Event{
+		EventType: events.DownloadDeleted,
+		Payload:   id,
+	}
+
 	if ok {
 		q.mu.Lock()
 		delete(q.inProgressHandlers, id)
@@ -214,3 +391,19 @@ func (q *queueManager) DeleteDownload(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+func (q *queueManager) discardDownloadedData(ctx context.Context, download state.Download) error {
+	if err := q.queries.DeleteDownloadChunksByDownloadID(ctx, download.ID); err != nil {
+		slog.Error("failed to delete download chunks", "downloadID", download.ID, "error", err)
+		return fmt.Errorf("failed to delete download chunks: %w", err)
+	}
+
+	partPath := q.partFiles.Path(download.ID, download.SavePath)
+	if err := os.Remove(partPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to remove downloaded file", "path", partPath, "error", err)
+		return fmt.Errorf("failed to remove downloaded file: %w", err)
+	}
+
+	slog.Info("discarded downloaded data, download will restart from scratch", "downloadID", download.ID)
+	return nil
+}
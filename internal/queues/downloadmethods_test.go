@@ -0,0 +1,168 @@
+package queues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func createTestQueue(t *testing.T, manager *queueManager, params state.CreateQueueParams) state.Queue {
+	t.Helper()
+	ctx := context.Background()
+
+	if params.Name == "" {
+		params.Name = "test"
+	}
+	if params.Directory == "" {
+		params.Directory = t.TempDir()
+	}
+	if params.MaxConcurrent == 0 {
+		params.MaxConcurrent = 2
+	}
+	if err := manager.CreateQueue(ctx, params); err != nil {
+		t.Fatalf("CreateQueue() error = %v", err)
+	}
+
+	queues, err := manager.ListQueue(ctx)
+	if err != nil {
+		t.Fatalf("ListQueue() error = %v", err)
+	}
+	for _, queue := range queues {
+		if queue.Name == params.Name {
+			return queue
+		}
+	}
+	t.Fatalf("ListQueue() has no queue named %q", params.Name)
+	return state.Queue{}
+}
+
+func TestDeleteDownloadRemovesHeaders(t *testing.T) {
+	manager := newTestQueueManager(t)
+	ctx := context.Background()
+	queue := createTestQueue(t, manager, state.CreateQueueParams{})
+	server := stallingServer(t)
+
+	id, err := manager.CreateDownload(ctx, CreateDownloadParams{
+		URL:      server.URL + "/file.bin",
+		FileName: "file.bin",
+		QueueID:  queue.ID,
+		Headers:  map[string]string{"Authorization": "Bearer secret", "Cookie": "session=abc"},
+	})
+	if err != nil {
+		t.Fatalf("CreateDownload() error = %v", err)
+	}
+
+	headers, err := manager.queries.GetDownloadHeadersByDownloadID(ctx, id)
+	if err != nil || len(headers) != 2 {
+		t.Fatalf("GetDownloadHeadersByDownloadID() = %v, %v, want two headers", headers, err)
+	}
+
+	if err := manager.DeleteDownload(ctx, id); err != nil {
+		t.Fatalf("DeleteDownload() error = %v", err)
+	}
+
+	headers, err = manager.queries.GetDownloadHeadersByDownloadID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetDownloadHeadersByDownloadID() error = %v", err)
+	}
+	if len(headers) != 0 {
+		t.Errorf("GetDownloadHeadersByDownloadID() after delete = %v, want none", headers)
+	}
+}
+
+func TestDeleteQueueRemovesDownloads(t *testing.T) {
+	manager := newTestQueueManager(t)
+	ctx := context.Background()
+	queue := createTestQueue(t, manager, state.CreateQueueParams{})
+	server := stallingServer(t)
+
+	id, err := manager.CreateDownload(ctx, CreateDownloadParams{
+		URL:      server.URL + "/file.bin",
+		FileName: "file.bin",
+		QueueID:  queue.ID,
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("CreateDownload() error = %v", err)
+	}
+
+	if err := manager.DeleteQueue(ctx, queue.ID); err != nil {
+		t.Fatalf("DeleteQueue() error = %v", err)
+	}
+
+	manager.mu.RLock()
+	_, running := manager.inProgressHandlers[id]
+	manager.mu.RUnlock()
+	if running {
+		t.Error("download handler is still running after its queue was deleted")
+	}
+
+	if _, err := manager.queries.GetDownload(ctx, id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetDownload() after queue delete error = %v, want %v", err, sql.ErrNoRows)
+	}
+	headers, err := manager.queries.GetDownloadHeadersByDownloadID(ctx, id)
+	if err != nil || len(headers) != 0 {
+		t.Errorf("GetDownloadHeadersByDownloadID() after queue delete = %v, %v, want none", headers, err)
+	}
+}
+
+func TestResumeDownloadInProgress(t *testing.T) {
+	manager := newTestQueueManager(t)
+	ctx := context.Background()
+	queue := createTestQueue(t, manager, state.CreateQueueParams{})
+	server := stallingServer(t)
+
+	id, err := manager.CreateDownload(ctx, CreateDownloadParams{
+		URL:      server.URL + "/file.bin",
+		FileName: "file.bin",
+		QueueID:  queue.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateDownload() error = %v", err)
+	}
+
+	manager.mu.RLock()
+	handler := manager.inProgressHandlers[id]
+	manager.mu.RUnlock()
+	if handler == nil {
+		t.Fatal("download did not start")
+	}
+
+	if err := manager.ResumeDownload(ctx, id); !errors.Is(err, ErrDownloadInProgress) {
+		t.Fatalf("ResumeDownload() of a running download error = %v, want %v", err, ErrDownloadInProgress)
+	}
+
+	manager.mu.RLock()
+	current := manager.inProgressHandlers[id]
+	manager.mu.RUnlock()
+	if current != handler {
+		t.Fatal("ResumeDownload() replaced the running download handler")
+	}
+
+	if _, err := manager.queries.SetDownloadRetry(ctx, state.SetDownloadRetryParams{Retries: 2, ID: id}); err != nil {
+		t.Fatalf("SetDownloadRetry() error = %v", err)
+	}
+	if err := manager.RetryDownload(ctx, id); !errors.Is(err, ErrDownloadInProgress) {
+		t.Fatalf("RetryDownload() of a running download error = %v, want %v", err, ErrDownloadInProgress)
+	}
+	download, err := manager.queries.GetDownload(ctx, id)
+	if err != nil {
+		t.Fatalf("GetDownload() error = %v", err)
+	}
+	if download.Retries != 2 {
+		t.Errorf("retries after refused retry = %d, want 2", download.Retries)
+	}
+
+	if err := manager.PauseDownload(ctx, id); err != nil {
+		t.Fatalf("PauseDownload() error = %v", err)
+	}
+	if err := manager.ResumeDownload(ctx, id); err != nil {
+		t.Fatalf("ResumeDownload() of a paused download error = %v", err)
+	}
+	if err := manager.PauseDownload(ctx, id); err != nil {
+		t.Fatalf("PauseDownload() error = %v", err)
+	}
+}
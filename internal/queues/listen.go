@@ -8,19 +8,24 @@ import (
 	"github.com/computer-technology-team/download-manager.git/internal/events"
 )
 
-func Listen(q QueueManager, ctx context.Context) {
+func Listen(q Engine, ctx context.Context) {
 	eventChan := events.GetEventChannel()
 
 	for event := range eventChan {
 		switch event.EventType {
 		case events.DownloadFailed:
-			id := event.Payload.(events.DownloadFailedEvent).ID
-			q.DownloadFailed(ctx, id)
+			failed := event.Payload.(events.DownloadFailedEvent)
+			q.DownloadFailed(ctx, failed.ID, failed.Error)
+		case events.DownloadVerifying:
+			q.DownloadVerifying(ctx, event.Payload.(downloads.DownloadStatus).ID)
+		case events.DownloadProbed:
+			q.DownloadProbed(ctx, event.Payload.(events.DownloadProbedEvent))
 		case events.DownloadProgressed:
 			q.UpsertChunks(ctx, event.Payload.(downloads.DownloadStatus))
 		case events.DownloadCompleted:
-			id := event.Payload.(downloads.DownloadStatus).ID
-			q.DownloadCompleted(ctx, id)
+			status := event.Payload.(downloads.DownloadStatus)
+			q.UpsertChunks(ctx, status)
+			q.DownloadCompleted(ctx, status.ID)
 		default:
 			slog.Error("Unknown Event type", "eventType", event.EventType)
 		}
@@ -2,17 +2,49 @@ package queues
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
 	"github.com/computer-technology-team/download-manager.git/internal/events"
 	"github.com/computer-technology-team/download-manager.git/internal/state"
 )
 
 func (q *queueManager) CreateQueue(ctx context.Context, createQueueParams state.CreateQueueParams) error {
+	if createQueueParams.Connections == 0 {
+		createQueueParams.Connections = downloads.DefaultConnections
+	}
+	if err := validateConnections(createQueueParams.Connections); err != nil {
+		slog.Error("invalid queue connection count", "connections", createQueueParams.Connections, "error", err)
+		return err
+	}
+	if err := ValidateRetryLimit(createQueueParams.RetryLimit); err != nil {
+		slog.Error("invalid queue retry limit", "retryLimit", createQueueParams.RetryLimit, "error", err)
+		return err
+	}
+	if err := validateProxy(createQueueParams.Proxy, createQueueParams.NoProxy); err != nil {
+		slog.Error("invalid queue proxy", "error", err)
+		return err
+	}
+	if err := downloads.ValidateCookieFile(createQueueParams.CookieFile.String); err != nil {
+		slog.Error("invalid queue cookie file", "error", err)
+		return err
+	}
+	conflictPolicy, err := downloads.ParseConflictPolicy(createQueueParams.ConflictPolicy)
+	if err != nil {
+		slog.Error("invalid queue conflict policy", "error", err)
+		return err
+	}
+	createQueueParams.ConflictPolicy = string(conflictPolicy)
+	warnInsecureQueue(createQueueParams.Name, createQueueParams.TlsInsecure)
+
 	queue, err := q.queries.CreateQueue(ctx, createQueueParams)
 	if err != nil {
-		slog.Error("failed to create queue", "params", createQueueParams, "error", err)
+		slog.Error("failed to create queue", "name", createQueueParams.Name, "error", err)
 		return fmt.Errorf("failed to create queue: %w", err)
 	}
 
@@ -26,12 +58,34 @@ func (q *queueManager) CreateQueue(ctx context.Context, createQueueParams state.
 }
 
 func (q *queueManager) DeleteQueue(ctx context.Context, id int64) error {
-	err := q.queries.DeleteQueue(ctx, id)
+	queueDownloads, err := q.stopQueueDownloads(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = q.queries.DeleteQueue(ctx, id)
 	if err != nil {
 		slog.Error("failed to delete queue", "queueID", id, "error", err)
 		return fmt.Errorf("failed to delete queue: %w", err)
 	}
 
+	q.mu.Lock()
+	delete(q.queueLimiters, id)
+	delete(q.queueWindows, id)
+	q.mu.Unlock()
+
+	for _, download := range queueDownloads {
+		partPath := q.partFiles.Path(download.ID, download.SavePath)
+		if err := os.Remove(partPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to remove unfinished download file", "path", partPath, "error", err)
+		}
+
+		events.GetUIEventChannel() <- events.Event{
+			EventType: events.DownloadDeleted,
+			Payload:   download.ID,
+		}
+	}
+
 	events.GetUIEventChannel() <- events.Event{
 		EventType: events.QueueDeleted,
 		Payload:   id,
@@ -41,10 +95,66 @@ func (q *queueManager) DeleteQueue(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (q *queueManager) stopQueueDownloads(ctx context.Context, queueID int64) ([]state.Download, error) {
+	allDownloads, err := q.queries.ListDownloads(ctx)
+	if err != nil {
+		slog.Error("failed to list downloads of queue", "queueID", queueID, "error", err)
+		return nil, fmt.Errorf("failed to list downloads of queue: %w", err)
+	}
+
+	queueDownloads := lo.Filter(allDownloads, func(download state.Download, _ int) bool {
+		return download.QueueID == queueID
+	})
+
+	for _, download := range queueDownloads {
+		q.mu.Lock()
+		handler, ok := q.inProgressHandlers[download.ID]
+		delete(q.inProgressHandlers, download.ID)
+		q.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+		if err := handler.Pause(); err != nil {
+			slog.Error("failed to pause download handler", "downloadID", download.ID, "error", err)
+			return nil, fmt.Errorf("failed to pause download handler: %w", err)
+		}
+	}
+
+	return queueDownloads, nil
+}
+
 func (q *queueManager) EditQueue(ctx context.Context, arg state.UpdateQueueParams) error {
+	if arg.Connections == 0 {
+		arg.Connections = downloads.DefaultConnections
+	}
+	if err := validateConnections(arg.Connections); err != nil {
+		slog.Error("invalid queue connection count", "connections", arg.Connections, "error", err)
+		return err
+	}
+	if err := ValidateRetryLimit(arg.RetryLimit); err != nil {
+		slog.Error("invalid queue retry limit", "retryLimit", arg.RetryLimit, "error", err)
+		return err
+	}
+	if err := validateProxy(arg.Proxy, arg.NoProxy); err != nil {
+		slog.Error("invalid queue proxy", "error", err)
+		return err
+	}
+	if err := downloads.ValidateCookieFile(arg.CookieFile.String); err != nil {
+		slog.Error("invalid queue cookie file", "error", err)
+		return err
+	}
+	conflictPolicy, err := downloads.ParseConflictPolicy(arg.ConflictPolicy)
+	if err != nil {
+		slog.Error("invalid queue conflict policy", "error", err)
+		return err
+	}
+	arg.ConflictPolicy = string(conflictPolicy)
+	warnInsecureQueue(arg.Name, arg.TlsInsecure)
+
 	queue, err := q.queries.UpdateQueue(ctx, arg)
 	if err != nil {
-		slog.Error("failed to update queue", "params", arg, "error", err)
+		slog.Error("failed to update queue", "queueID", arg.ID, "name", arg.Name, "error", err)
 		return fmt.Errorf("failed to update queue: %w", err)
 	}
 
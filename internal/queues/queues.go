@@ -7,22 +7,49 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/computer-technology-team/download-manager.git/internal/bandwidthlimit"
 	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
 	"github.com/computer-technology-team/download-manager.git/internal/state"
 )
 
+const (
+	fileNameResolveTimeout = 30 * time.Second
+
+	MaxRetryLimit int64 = 10
+)
+
 var (
-	ErrEmptyFileName = errors.New("empty file name: URL does not contain a valid file name")
+	ErrEmptyFileName   = errors.New("empty file name: URL does not contain a valid file name")
+	ErrInvalidChecksum = errors.New("invalid checksum")
+
+	ErrInvalidConnections = errors.New("invalid connection count")
+	ErrInvalidRetryLimit  = errors.New("invalid retry limit")
+	ErrSavePathInUse      = errors.New("save path is used by another download")
+	ErrCredentialConflict = errors.New("host already has credentials of another scheme")
+	ErrDownloadInProgress = errors.New("download is already in progress")
 )
 
+type CreateDownloadParams struct {
+	URL      string `json:"url"`
+	FileName string `json:"file_name"`
+	QueueID  int64  `json:"queue_id"`
+	Checksum string `json:"checksum,omitempty"`
+
+	Connections int64             `json:"connections,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+
+	ReplaceCredentials bool `json:"replace_credentials,omitempty"`
+}
+
 type QueueManager interface {
 
 	PauseDownload(ctx context.Context, id int64) error
 	ResumeDownload(ctx context.Context, id int64) error
 	RetryDownload(ctx context.Context, id int64) error
-	CreateDownload(ctx context.Context, url, fileName string, queueID int64) error
+	CreateDownload(ctx context.Context, params CreateDownloadParams) (int64, error)
 	DeleteDownload(ctx context.Context, id int64) error
 
 	CreateQueue(ctx context.Context, createQueueParams state.CreateQueueParams) error
@@ -30,24 +57,99 @@ type QueueManager interface {
 	ListQueue(ctx context.Context) ([]state.Queue, error)
 	EditQueue(ctx context.Context, arg state.UpdateQueueParams) error
 
-	DownloadFailed(ctx context.Context, id int64) error
+	SetCredential(ctx context.Context, arg state.UpsertCredentialParams) error
+	ListCredentials(ctx context.Context) ([]state.Credential, error)
+	DeleteCredential(ctx context.Context, host string) error
+
+	ListDownloadsWithQueueName(ctx context.Context) ([]state.ListDownloadsWithQueueNameRow, error)
+}
+
+type Engine interface {
+	QueueManager
+
+	DownloadFailed(ctx context.Context, id int64, cause error) error
+	DownloadVerifying(ctx context.Context, id int64) error
+	DownloadProbed(ctx context.Context, probe events.DownloadProbedEvent) error
 	DownloadCompleted(ctx context.Context, id int64) error
 	UpsertChunks(ctx context.Context, status downloads.DownloadStatus) error
-	ListDownloadsWithQueueName(ctx context.Context) ([]state.ListDownloadsWithQueueNameRow, error)
+	EnforceSchedules(ctx context.Context) error
 }
 
 type queueManager struct {
 	queries            *state.Queries
 	inProgressHandlers map[int64]downloads.DownloadHandler
 	queueLimiters      map[int64]*bandwidthlimit.Limiter
+	queueWindows       map[int64]bool
+	clock              func() time.Time
+	timeouts           downloads.Timeouts
+	clients            downloads.HTTPClientFactory
+	netrcPath          string
+	partFiles          downloads.PartFiles
+	engineDisabled     bool
 	mu                 sync.RWMutex
+	savePathMu         sync.Mutex
+}
+
+type Option func(*queueManager)
+
+func WithClock(clock func() time.Time) Option {
+	return func(q *queueManager) {
+		if clock != nil {
+			q.clock = clock
+		}
+	}
+}
+
+func WithTimeouts(timeouts downloads.Timeouts) Option {
+	return func(q *queueManager) {
+		q.timeouts = timeouts
+	}
+}
+
+func WithHTTPClientFactory(clients downloads.HTTPClientFactory) Option {
+	return func(q *queueManager) {
+		q.clients = clients
+	}
+}
+
+func WithNetrc(path string) Option {
+	return func(q *queueManager) {
+		q.netrcPath = path
+	}
+}
+
+func WithPartFiles(partFiles downloads.PartFiles) Option {
+	return func(q *queueManager) {
+		q.partFiles = partFiles
+	}
 }
 
-func New(db *sql.DB) (QueueManager, error) {
+func WithoutEngine() Option {
+	return func(q *queueManager) {
+		q.engineDisabled = true
+	}
+}
+
+func New(db *sql.DB, opts ...Option) (Engine, error) {
 	qm := &queueManager{
 		queries:            state.New(db),
 		inProgressHandlers: make(map[int64]downloads.DownloadHandler),
 		queueLimiters:      make(map[int64]*bandwidthlimit.Limiter),
+		queueWindows:       make(map[int64]bool),
+		clock:              time.Now,
+		timeouts:           downloads.DefaultTimeouts(),
+		netrcPath:          downloads.DefaultNetrcPath(),
+		partFiles:          downloads.DefaultPartFiles(),
+	}
+
+	for _, opt := range opts {
+		opt(qm)
+	}
+
+	if qm.clients == nil {
+		transport := downloads.DefaultTransportConfig()
+		transport.Timeouts = qm.timeouts
+		qm.clients = downloads.NewHTTPClientFactory(transport)
 	}
 
 	if err := qm.init(context.Background()); err != nil {
@@ -68,7 +170,9 @@ func (q *queueManager) init(ctx context.Context) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	queuesByID := make(map[int64]state.Queue, len(queues))
 	for _, queue := range queues {
+		queuesByID[queue.ID] = queue
 		if queue.MaxBandwidth.Valid {
 			q.queueLimiters[queue.ID] = bandwidthlimit.NewLimiter(&queue.MaxBandwidth.Int64)
 		} else {
@@ -76,13 +180,40 @@ func (q *queueManager) init(ctx context.Context) error {
 		}
 	}
 
+	if q.engineDisabled {
+		slog.Info("initialized without download engine")
+		return nil
+	}
+
 	inProgressDownloads, err := q.queries.GetDownloadsByStatus(ctx, string(downloads.StateInProgress))
 	if err != nil {
 		slog.Error("failed to get in-progress downloads during initialization", "error", err)
 		return fmt.Errorf("failed to get in-progress downloads during initialization: %w", err)
 	}
 
+	verifyingDownloads, err := q.queries.GetDownloadsByStatus(ctx, string(downloads.StateVerifying))
+	if err != nil {
+		slog.Error("failed to get verifying downloads during initialization", "error", err)
+		return fmt.Errorf("failed to get verifying downloads during initialization: %w", err)
+	}
+	inProgressDownloads = append(inProgressDownloads, verifyingDownloads...)
+
+	now := q.clock()
+
 	for _, download := range inProgressDownloads {
+		if !inDownloadWindow(queuesByID[download.QueueID], now) {
+			if _, err := q.queries.SetDownloadState(ctx, state.SetDownloadStateParams{
+				State: string(downloads.StatePending),
+				ID:    download.ID,
+			}); err != nil {
+				slog.Error("failed to defer in-progress download outside queue window", "downloadID", download.ID, "error", err)
+				return fmt.Errorf("failed to defer in-progress download: %w", err)
+			}
+
+			slog.Info("deferred in-progress download until queue window opens", "downloadID", download.ID)
+			continue
+		}
+
 		downloadChunks, err := q.queries.GetDownloadChunksByDownloadID(ctx, download.ID)
 		if err != nil {
 			slog.Error("failed to get download chunks for in-progress download", "downloadID", download.ID, "error", err)
@@ -95,7 +226,36 @@ func (q *queueManager) init(ctx context.Context) error {
 			return fmt.Errorf("limiter not found for queue %d", download.QueueID)
 		}
 
-		handler, err := downloads.NewDownloadHandler(download, downloadChunks, limiter)
+		connections, err := q.downloadConnections(ctx, download)
+		if err != nil {
+			return err
+		}
+
+		client, err := q.downloadClient(ctx, download)
+		if err != nil {
+			return err
+		}
+
+		headers, err := q.downloadHeaders(ctx, download.ID)
+		if err != nil {
+			return err
+		}
+
+		var conflictPolicy downloads.ConflictPolicy
+		if len(downloadChunks) == 0 {
+			download, conflictPolicy, err = q.resolveStartConflict(ctx, download)
+			if err != nil {
+				return err
+			}
+		}
+
+		handler, err := downloads.NewDownloadHandler(download, downloadChunks, connections, limiter, client, headers, conflictPolicy, q.partFiles, q.timeouts)
+		if errors.Is(err, downloads.ErrFileExists) {
+			if err := q.skipDownload(ctx, download, err); err != nil {
+				return err
+			}
+			continue
+		}
 		if err != nil {
 			slog.Error("failed to initilize download handler", "error", err)
 			return err
@@ -0,0 +1,114 @@
+package queues
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+const scheduleCheckPeriod = time.Second
+
+func Schedule(q Engine, ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		if err := q.EnforceSchedules(ctx); err != nil {
+			slog.Error("failed to enforce queue schedules", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *queueManager) EnforceSchedules(ctx context.Context) error {
+	queues, err := q.queries.ListQueues(ctx)
+	if err != nil {
+		slog.Error("failed to list queues for scheduling", "error", err)
+		return fmt.Errorf("failed to list queues for scheduling: %w", err)
+	}
+
+	now := q.clock()
+
+	for _, queue := range queues {
+		open := inDownloadWindow(queue, now)
+
+		q.mu.Lock()
+		wasOpen, known := q.queueWindows[queue.ID]
+		q.queueWindows[queue.ID] = open
+		q.mu.Unlock()
+
+		if known && wasOpen == open {
+			continue
+		}
+
+		if open {
+			slog.Info("queue download window opened", "queueID", queue.ID)
+			err = q.startNextDownloadIfPossible(ctx, queue.ID)
+		} else {
+			slog.Info("queue download window closed", "queueID", queue.ID)
+			err = q.suspendQueue(ctx, queue.ID)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q *queueManager) suspendQueue(ctx context.Context, queueID int64) error {
+	q.mu.Lock()
+	suspended := make(map[int64]downloads.DownloadHandler)
+	for id, handler := range q.inProgressHandlers {
+		download, err := q.queries.GetDownload(ctx, id)
+		if err != nil {
+			q.mu.Unlock()
+			slog.Error("failed to get download details", "downloadID", id, "error", err)
+			return fmt.Errorf("failed to get download details: %w", err)
+		}
+		if download.QueueID == queueID {
+			suspended[id] = handler
+			delete(q.inProgressHandlers, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for id, handler := range suspended {
+		if err := handler.Pause(); err != nil {
+			slog.Error("failed to pause download handler", "downloadID", id, "error", err)
+			return fmt.Errorf("failed to pause download handler: %w", err)
+		}
+
+		if err := q.setDownloadState(ctx, id, string(downloads.StatePending)); err != nil {
+			return err
+		}
+
+		slog.Info("download suspended until queue window reopens", "downloadID", id, "queueID", queueID)
+	}
+
+	return nil
+}
+
+func (q *queueManager) isQueueOpen(ctx context.Context, queueID int64) (bool, error) {
+	queue, err := q.queries.GetQueue(ctx, queueID)
+	if err != nil {
+		slog.Error("failed to get queue details", "queueID", queueID, "error", err)
+		return false, fmt.Errorf("failed to get queue details: %w", err)
+	}
+
+	return inDownloadWindow(queue, q.clock()), nil
+}
+
+func inDownloadWindow(queue state.Queue, now time.Time) bool {
+	return !queue.ScheduleMode || queue.Schedule.Active(now)
+}
@@ -0,0 +1,146 @@
+package queues
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
+	"github.com/computer-technology-team/download-manager.git/internal/events"
+	"github.com/computer-technology-team/download-manager.git/internal/state"
+)
+
+func newTestQueueManager(t *testing.T, opts ...Option) *queueManager {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go drainEvents(ctx, events.GetEventChannel())
+	go drainEvents(ctx, events.GetUIEventChannel())
+
+	db, err := state.OpenDatabase(ctx, filepath.Join(t.TempDir(), "sqlite.db"))
+	if err != nil {
+		t.Fatalf("OpenDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	manager, err := New(db, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return manager.(*queueManager)
+}
+
+func drainEvents(ctx context.Context, source <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-source:
+		}
+	}
+}
+
+func stallingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "1048576")
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEnforceSchedules(t *testing.T) {
+	monday := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.Local)
+	notAfter := monday.AddDate(0, 0, 5)
+
+	now := monday.Add(-12 * time.Hour)
+	manager := newTestQueueManager(t, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	rule, err := state.ParseScheduleRule("mon-fri 09:00-17:00")
+	if err != nil {
+		t.Fatalf("ParseScheduleRule() error = %v", err)
+	}
+
+	if err := manager.CreateQueue(ctx, state.CreateQueueParams{
+		Name:          "office hours",
+		Directory:     t.TempDir(),
+		Schedule:      state.Schedule{Rules: []state.ScheduleRule{rule}, NotAfter: &notAfter, Valid: true},
+		RetryLimit:    3,
+		MaxConcurrent: 1,
+		ScheduleMode:  true,
+	}); err != nil {
+		t.Fatalf("CreateQueue() error = %v", err)
+	}
+
+	queues, err := manager.ListQueue(ctx)
+	if err != nil || len(queues) != 1 {
+		t.Fatalf("ListQueue() = %v, %v, want one queue", queues, err)
+	}
+
+	server := stallingServer(t)
+	id, err := manager.CreateDownload(ctx, CreateDownloadParams{
+		URL:      server.URL + "/file.bin",
+		FileName: "file.bin",
+		QueueID:  queues[0].ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateDownload() error = %v", err)
+	}
+
+	steps := []struct {
+		name      string
+		now       time.Time
+		wantState downloads.DownloadState
+		wantOpen  bool
+	}{
+		{name: "closed on sunday", now: monday.Add(-12 * time.Hour), wantState: downloads.StatePending},
+		{name: "closed before opening time", now: monday.Add(8 * time.Hour), wantState: downloads.StatePending},
+		{name: "opens at nine", now: monday.Add(9 * time.Hour), wantState: downloads.StateInProgress, wantOpen: true},
+		{name: "stays open", now: monday.Add(16 * time.Hour), wantState: downloads.StateInProgress, wantOpen: true},
+		{name: "closes at five", now: monday.Add(17 * time.Hour), wantState: downloads.StatePending},
+		{name: "stays closed overnight", now: monday.Add(24*time.Hour + 2*time.Hour), wantState: downloads.StatePending},
+		{name: "reopens next day", now: monday.Add(24*time.Hour + 9*time.Hour), wantState: downloads.StateInProgress, wantOpen: true},
+		{name: "closed after the end date", now: notAfter.Add(3*24*time.Hour + 10*time.Hour), wantState: downloads.StatePending},
+	}
+
+	for _, step := range steps {
+		now = step.now
+
+		if err := manager.EnforceSchedules(ctx); err != nil {
+			t.Fatalf("%s: EnforceSchedules() error = %v", step.name, err)
+		}
+
+		download, err := manager.queries.GetDownload(ctx, id)
+		if err != nil {
+			t.Fatalf("%s: GetDownload() error = %v", step.name, err)
+		}
+		if download.State != string(step.wantState) {
+			t.Errorf("%s: download state = %s, want %s", step.name, download.State, step.wantState)
+		}
+
+		manager.mu.RLock()
+		_, running := manager.inProgressHandlers[id]
+		open := manager.queueWindows[queues[0].ID]
+		manager.mu.RUnlock()
+
+		if running != (step.wantState == downloads.StateInProgress) {
+			t.Errorf("%s: handler running = %v, want %v", step.name, running, !running)
+		}
+		if open != step.wantOpen {
+			t.Errorf("%s: queue window open = %v, want %v", step.name, open, step.wantOpen)
+		}
+	}
+}
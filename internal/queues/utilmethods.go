@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 
 	"github.com/computer-technology-team/download-manager.git/internal/downloads"
 	"github.com/computer-technology-team/download-manager.git/internal/events"
@@ -29,6 +31,19 @@ func (q *queueManager) setDownloadState(ctx context.Context, id int64, downloadS
 }
 
 func (q *queueManager) startNextDownloadIfPossible(ctx context.Context, queueID int64) error {
+	if q.engineDisabled {
+		return nil
+	}
+
+	for {
+		started, err := q.startNextDownload(ctx, queueID)
+		if err != nil || !started {
+			return err
+		}
+	}
+}
+
+func (q *queueManager) startNextDownload(ctx context.Context, queueID int64) (bool, error) {
 	var activeDownloads int64 = 0
 
 	q.mu.RLock()
@@ -37,7 +52,7 @@ func (q *queueManager) startNextDownloadIfPossible(ctx context.Context, queueID
 		if err != nil {
 			q.mu.RUnlock()
 			slog.Error("failed to get download details", "downloadID", id, "error", err)
-			return fmt.Errorf("failed to get download details: %w", err)
+			return false, fmt.Errorf("failed to get download details: %w", err)
 		}
 		if download.QueueID == queueID {
 			activeDownloads++
@@ -48,29 +63,64 @@ func (q *queueManager) startNextDownloadIfPossible(ctx context.Context, queueID
 	queue, err := q.queries.GetQueue(ctx, queueID)
 	if err != nil {
 		slog.Error("failed to get queue details", "queueID", queueID, "error", err)
-		return fmt.Errorf("failed to get queue details: %w", err)
+		return false, fmt.Errorf("failed to get queue details: %w", err)
+	}
+
+	if !inDownloadWindow(queue, q.clock()) {
+		slog.Debug("queue is outside its download window, not starting next download", "queueID", queueID)
+		return false, nil
 	}
 
 	if activeDownloads >= queue.MaxConcurrent {
 		slog.Info("queue is full, cannot start next download", "queueID", queueID, "activeDownloads", activeDownloads, "maxConcurrent", queue.MaxConcurrent)
-		return nil
+		return false, nil
 	}
 
 	nextDownload, err := q.queries.GetPendingDownloadByQueueID(ctx, queueID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil
+			return false, nil
 		}
 		slog.Error("failed to get pending download by queue ID", "queueID", queueID, "error", err)
-		return err
+		return false, err
 	}
 
 	if err := q.ResumeDownload(ctx, nextDownload.ID); err != nil {
-		slog.Error("failed to resume download", "downloadID", nextDownload.ID, "error", err)
-		return fmt.Errorf("failed to resume download: %w", err)
+		slog.Error("failed to resume download, moving on to the next one", "downloadID", nextDownload.ID, "error", err)
+		if err := q.markDownloadFailed(ctx, nextDownload, err); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
 
 	slog.Info("started next download", "downloadID", nextDownload.ID)
+	return true, nil
+}
+
+func (q *queueManager) markDownloadFailed(ctx context.Context, download state.Download, cause error) error {
+	if err := q.queries.SetDownloadLastError(ctx, state.SetDownloadLastErrorParams{
+		LastError: sql.NullString{String: cause.Error(), Valid: true},
+		ID:        download.ID,
+	}); err != nil {
+		slog.Error("failed to save download error", "downloadID", download.ID, "error", err)
+		return fmt.Errorf("failed to save download error: %w", err)
+	}
+
+	if err := q.setDownloadState(ctx, download.ID, string(downloads.StateFailed)); err != nil {
+		return err
+	}
+
+	events.GetUIEventChannel() <- events.Event{
+		EventType: events.DownloadFailed,
+		Payload: events.DownloadFailedEvent{
+			ID:        download.ID,
+			URL:       download.Url,
+			Error:     cause,
+			Kind:      string(downloads.ErrorKindOf(cause)),
+			Permanent: downloads.IsPermanent(cause),
+		},
+	}
+
 	return nil
 }
 
@@ -87,6 +137,114 @@ func (q *queueManager) startNextDownloadIfPossibleByDownloadID(ctx context.Conte
 	return q.startNextDownloadIfPossible(ctx, queueID)
 }
 
+func (q *queueManager) downloadConnections(ctx context.Context, download state.Download) (int64, error) {
+	if download.Connections.Valid {
+		return download.Connections.Int64, nil
+	}
+
+	queue, err := q.queries.GetQueue(ctx, download.QueueID)
+	if err != nil {
+		slog.Error("failed to get queue details", "queueID", download.QueueID, "error", err)
+		return 0, fmt.Errorf("failed to get queue details: %w", err)
+	}
+
+	return queue.Connections, nil
+}
+
+func (q *queueManager) downloadClient(ctx context.Context, download state.Download) (*http.Client, error) {
+	queue, err := q.queries.GetQueue(ctx, download.QueueID)
+	if err != nil {
+		slog.Error("failed to get queue details", "queueID", download.QueueID, "error", err)
+		return nil, fmt.Errorf("failed to get queue details: %w", err)
+	}
+
+	target, err := url.Parse(download.Url)
+	if err != nil {
+		slog.Error("failed to parse download URL", "url", download.Url, "error", err)
+		return nil, fmt.Errorf("failed to parse download URL: %w", err)
+	}
+
+	client, err := q.clients.Client(downloads.ClientOptions{
+		Host:               target.Host,
+		Proxy:              queueProxy(queue),
+		CookieFile:         queue.CookieFile.String,
+		InsecureSkipVerify: queue.TlsInsecure,
+	})
+	if err != nil {
+		slog.Error("failed to create http client for queue", "queueID", queue.ID, "error", err)
+		return nil, fmt.Errorf("failed to create http client for queue %d: %w", queue.ID, err)
+	}
+
+	credentials, err := q.downloadCredentials(ctx, download.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	return downloads.WithCredentials(client, credentials), nil
+}
+
+func (q *queueManager) resolveFileName(ctx context.Context, queueID int64, target *url.URL, headers map[string]string) string {
+	client, err := q.downloadClient(ctx, state.Download{QueueID: queueID, Url: target.String()})
+	if err != nil {
+		return downloads.FileNameFromURL(target)
+	}
+
+	requestHeaders := make(http.Header, len(headers))
+	for name, value := range headers {
+		requestHeaders.Set(name, value)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fileNameResolveTimeout)
+	defer cancel()
+
+	fileName, err := downloads.ResolveFileName(ctx, client, target.String(), requestHeaders)
+	if err != nil {
+		slog.Warn("could not resolve file name from the server, using the URL", "url", target.Redacted(), "error", err)
+		return downloads.FileNameFromURL(target)
+	}
+
+	slog.Info("resolved download file name", "url", target.Redacted(), "fileName", fileName)
+	return fileName
+}
+
+func (q *queueManager) downloadHeaders(ctx context.Context, id int64) (http.Header, error) {
+	rows, err := q.queries.GetDownloadHeadersByDownloadID(ctx, id)
+	if err != nil {
+		slog.Error("failed to get download headers", "downloadID", id, "error", err)
+		return nil, fmt.Errorf("failed to get download headers: %w", err)
+	}
+
+	return downloads.RequestHeaders(rows), nil
+}
+
+func queueProxy(queue state.Queue) downloads.ProxyConfig {
+	return downloads.ProxyConfig{URL: queue.Proxy.String, NoProxy: queue.NoProxy.String}
+}
+
+func warnInsecureQueue(name string, insecure bool) {
+	if insecure {
+		slog.Warn("TLS certificate verification is DISABLED for queue, its downloads can be intercepted", "queue", name)
+	}
+}
+
+func validateProxy(proxy, noProxy sql.NullString) error {
+	return downloads.ProxyConfig{URL: proxy.String, NoProxy: noProxy.String}.Validate()
+}
+
+func validateConnections(connections int64) error {
+	if connections < 1 || connections > downloads.MaxConnections {
+		return fmt.Errorf("%w: must be between 1 and %d", ErrInvalidConnections, downloads.MaxConnections)
+	}
+	return nil
+}
+
+func ValidateRetryLimit(retryLimit int64) error {
+	if retryLimit < 0 || retryLimit > MaxRetryLimit {
+		return fmt.Errorf("%w: must be between 0 and %d", ErrInvalidRetryLimit, MaxRetryLimit)
+	}
+	return nil
+}
+
 func (q *queueManager) ListDownloadsWithQueueName(ctx context.Context) ([]state.ListDownloadsWithQueueNameRow, error) {
 	downloads, err := q.queries.ListDownloadsWithQueueName(ctx)
 	if err != nil {
@@ -112,10 +270,33 @@ func (q *queueManager) UpsertChunks(ctx context.Context, status downloads.Downlo
 		}
 	}
 
+	if err := q.queries.SetDownloadProgress(ctx, state.SetDownloadProgressParams{
+		BytesDownloaded: status.Downloaded,
+		TotalSize:       sql.NullInt64{Int64: status.TotalSize, Valid: status.SizeKnown()},
+		ID:              status.ID,
+	}); err != nil {
+		slog.Error("Could not save download progress", "downloadID", status.ID, "error", err)
+		errs = append(errs, err)
+	}
+
 	return errors.Join(errs...)
 }
 
-func (q *queueManager) DownloadFailed(ctx context.Context, id int64) error {
+func (q *queueManager) DownloadFailed(ctx context.Context, id int64, cause error) error {
+
+	if cause != nil {
+		if err := q.queries.SetDownloadLastError(ctx, state.SetDownloadLastErrorParams{
+			LastError: sql.NullString{String: cause.Error(), Valid: true},
+			ID:        id,
+		}); err != nil {
+			slog.Error("failed to save download error", "downloadID", id, "error", err)
+			return fmt.Errorf("failed to save download error: %w", err)
+		}
+	}
+
+	if errors.Is(cause, downloads.ErrChecksumMismatch) {
+		return q.downloadChecksumMismatch(ctx, id)
+	}
 
 	download, err := q.queries.GetDownload(ctx, id)
 	if err != nil {
@@ -123,13 +304,26 @@ func (q *queueManager) DownloadFailed(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to get download details: %w", err)
 	}
 
+	if errors.Is(cause, downloads.ErrResourceChanged) || errors.Is(cause, downloads.ErrSizeMismatch) {
+		slog.Warn("downloaded data can not be trusted, discarding it", "downloadID", id, "error", cause)
+		if err := q.discardDownloadedData(ctx, download); err != nil {
+			return err
+		}
+	}
+
 	queue, err := q.queries.GetQueue(ctx, download.QueueID)
 	if err != nil {
 		slog.Error("failed to get queue details", "queueID", download.QueueID, "error", err)
 		return fmt.Errorf("failed to get queue details: %w", err)
 	}
 
-	if download.Retries < queue.RetryLimit {
+	permanent := downloads.IsPermanent(cause)
+	if permanent {
+		slog.Warn("download failed with a permanent error, not retrying", "downloadID", id,
+			"kind", downloads.ErrorKindOf(cause), "error", cause)
+	}
+
+	if !permanent && download.Retries < queue.RetryLimit {
 
 		if _, err := q.queries.SetDownloadRetry(ctx, state.SetDownloadRetryParams{
 			Retries: download.Retries + 1,
@@ -139,6 +333,10 @@ func (q *queueManager) DownloadFailed(ctx context.Context, id int64) error {
 			return fmt.Errorf("failed to set download retry count: %w", err)
 		}
 
+		q.mu.Lock()
+		delete(q.inProgressHandlers, id)
+		q.mu.Unlock()
+
 		if err := q.ResumeDownload(ctx, id); err != nil {
 			slog.Error("failed to retry download", "downloadID", id, "error", err)
 			return fmt.Errorf("failed to retry download: %w", err)
@@ -167,13 +365,85 @@ func (q *queueManager) DownloadFailed(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (q *queueManager) downloadChecksumMismatch(ctx context.Context, id int64) error {
+	if err := q.setDownloadState(ctx, id, string(downloads.StateChecksumMismatch)); err != nil {
+		slog.Error("failed to set download state to checksum mismatch", "downloadID", id, "error", err)
+		return fmt.Errorf("failed to set download state to checksum mismatch: %w", err)
+	}
+
+	q.mu.Lock()
+	delete(q.inProgressHandlers, id)
+	q.mu.Unlock()
+
+	slog.Info("download failed checksum verification", "downloadID", id)
+
+	if err := q.startNextDownloadIfPossibleByDownloadID(ctx, id); err != nil {
+		slog.Error("failed to start next download in queue", "downloadID", id, "error", err)
+		return fmt.Errorf("failed to start next download in queue: %w", err)
+	}
+
+	return nil
+}
+
+func (q *queueManager) DownloadVerifying(ctx context.Context, id int64) error {
+	if err := q.setDownloadState(ctx, id, string(downloads.StateVerifying)); err != nil {
+		slog.Error("failed to set download state to verifying", "downloadID", id, "error", err)
+		return fmt.Errorf("failed to set download state to verifying: %w", err)
+	}
+
+	return nil
+}
+
+func (q *queueManager) DownloadProbed(ctx context.Context, probe events.DownloadProbedEvent) error {
+	if probe.Restarted {
+		if err := q.queries.DeleteDownloadChunksByDownloadID(ctx, probe.ID); err != nil {
+			slog.Error("failed to delete stale download chunks", "downloadID", probe.ID, "error", err)
+			return fmt.Errorf("failed to delete stale download chunks: %w", err)
+		}
+		slog.Info("download restarted from scratch, stale chunks deleted", "downloadID", probe.ID)
+	}
+
+	if err := q.queries.SetDownloadValidators(ctx, state.SetDownloadValidatorsParams{
+		Etag:          sql.NullString{String: probe.ETag, Valid: probe.ETag != ""},
+		LastModified:  sql.NullString{String: probe.LastModified, Valid: probe.LastModified != ""},
+		ContentLength: sql.NullInt64{Int64: probe.ContentLength, Valid: probe.ContentLength >= 0},
+		ID:            probe.ID,
+	}); err != nil {
+		slog.Error("failed to store download validators", "downloadID", probe.ID, "error", err)
+		return fmt.Errorf("failed to store download validators: %w", err)
+	}
+
+	if err := q.queries.SetDownloadContentInfo(ctx, state.SetDownloadContentInfoParams{
+		TotalSize:   sql.NullInt64{Int64: probe.ContentLength, Valid: probe.ContentLength >= 0},
+		ContentType: sql.NullString{String: probe.ContentType, Valid: probe.ContentType != ""},
+		ID:          probe.ID,
+	}); err != nil {
+		slog.Error("failed to store download content info", "downloadID", probe.ID, "error", err)
+		return fmt.Errorf("failed to store download content info: %w", err)
+	}
+
+	return nil
+}
+
 func (q *queueManager) DownloadCompleted(ctx context.Context, id int64) error {
 
+	if err := q.queries.SetDownloadCompleted(ctx, state.SetDownloadCompletedParams{
+		CompletedAt: sql.NullTime{Time: q.clock(), Valid: true},
+		ID:          id,
+	}); err != nil {
+		slog.Error("failed to save download completion time", "downloadID", id, "error", err)
+		return fmt.Errorf("failed to save download completion time: %w", err)
+	}
+
 	if err := q.setDownloadState(ctx, id, string(downloads.StateCompleted)); err != nil {
 		slog.Error("failed to set download state to completed", "downloadID", id, "error", err)
 		return fmt.Errorf("failed to set download state to completed: %w", err)
 	}
 
+	q.mu.Lock()
+	delete(q.inProgressHandlers, id)
+	q.mu.Unlock()
+
 	slog.Info("download marked as completed", "downloadID", id)
 
 	if err := q.startNextDownloadIfPossibleByDownloadID(ctx, id); err != nil {
@@ -4,20 +4,24 @@ package state
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createDownload = `-- name: CreateDownload :one
-INSERT INTO downloads (queue_id, url, save_path, state, retries)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, queue_id, url, save_path, state, retries
+INSERT INTO downloads (queue_id, url, save_path, state, retries, checksum, connections, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type
 `
 
 type CreateDownloadParams struct {
-	QueueID  int64
-	Url      string
-	SavePath string
-	State    string
-	Retries  int64
+	QueueID     int64
+	Url         string
+	SavePath    string
+	State       string
+	Retries     int64
+	Checksum    sql.NullString
+	Connections sql.NullInt64
+	CreatedAt   sql.NullTime
 }
 
 func (q *Queries) CreateDownload(ctx context.Context, arg CreateDownloadParams) (Download, error) {
@@ -27,6 +31,9 @@ func (q *Queries) CreateDownload(ctx context.Context, arg CreateDownloadParams)
 		arg.SavePath,
 		arg.State,
 		arg.Retries,
+		arg.Checksum,
+		arg.Connections,
+		arg.CreatedAt,
 	)
 	var i Download
 	err := row.Scan(
@@ -36,10 +43,38 @@ func (q *Queries) CreateDownload(ctx context.Context, arg CreateDownloadParams)
 		&i.SavePath,
 		&i.State,
 		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
 	)
 	return i, err
 }
 
+const createDownloadHeader = `-- name: CreateDownloadHeader :exec
+INSERT INTO download_headers (download_id, name, value)
+VALUES (?, ?, ?)
+`
+
+type CreateDownloadHeaderParams struct {
+	DownloadID int64
+	Name       string
+	Value      string
+}
+
+func (q *Queries) CreateDownloadHeader(ctx context.Context, arg CreateDownloadHeaderParams) error {
+	_, err := q.db.ExecContext(ctx, createDownloadHeader, arg.DownloadID, arg.Name, arg.Value)
+	return err
+}
+
 const deleteDownload = `-- name: DeleteDownload :exec
 DELETE FROM downloads
 WHERE id = ?
@@ -60,8 +95,18 @@ func (q *Queries) DeleteDownloadChunk(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteDownloadChunksByDownloadID = `-- name: DeleteDownloadChunksByDownloadID :exec
+DELETE FROM download_chunks
+WHERE download_id = ?
+`
+
+func (q *Queries) DeleteDownloadChunksByDownloadID(ctx context.Context, downloadID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteDownloadChunksByDownloadID, downloadID)
+	return err
+}
+
 const getDownload = `-- name: GetDownload :one
-SELECT id, queue_id, url, save_path, state, retries FROM downloads
+SELECT id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type FROM downloads
 WHERE id = ?
 `
 
@@ -75,6 +120,49 @@ func (q *Queries) GetDownload(ctx context.Context, id int64) (Download, error) {
 		&i.SavePath,
 		&i.State,
 		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
+	)
+	return i, err
+}
+
+const getDownloadBySavePath = `-- name: GetDownloadBySavePath :one
+SELECT id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type FROM downloads
+WHERE save_path = ?
+`
+
+func (q *Queries) GetDownloadBySavePath(ctx context.Context, savePath string) (Download, error) {
+	row := q.db.QueryRowContext(ctx, getDownloadBySavePath, savePath)
+	var i Download
+	err := row.Scan(
+		&i.ID,
+		&i.QueueID,
+		&i.Url,
+		&i.SavePath,
+		&i.State,
+		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
 	)
 	return i, err
 }
@@ -133,8 +221,42 @@ func (q *Queries) GetDownloadChunksByDownloadID(ctx context.Context, downloadID
 	return items, nil
 }
 
+const getDownloadHeadersByDownloadID = `-- name: GetDownloadHeadersByDownloadID :many
+SELECT id, download_id, name, value FROM download_headers
+WHERE download_id = ?
+ORDER BY id
+`
+
+func (q *Queries) GetDownloadHeadersByDownloadID(ctx context.Context, downloadID int64) ([]DownloadHeader, error) {
+	rows, err := q.db.QueryContext(ctx, getDownloadHeadersByDownloadID, downloadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DownloadHeader
+	for rows.Next() {
+		var i DownloadHeader
+		if err := rows.Scan(
+			&i.ID,
+			&i.DownloadID,
+			&i.Name,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getDownloadsByStatus = `-- name: GetDownloadsByStatus :many
-SELECT id, queue_id, url, save_path, state, retries 
+SELECT id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type 
 FROM downloads
 WHERE state = ?
 `
@@ -155,6 +277,18 @@ func (q *Queries) GetDownloadsByStatus(ctx context.Context, state string) ([]Dow
 			&i.SavePath,
 			&i.State,
 			&i.Retries,
+			&i.Checksum,
+			&i.Etag,
+			&i.LastModified,
+			&i.ContentLength,
+			&i.Connections,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.TotalSize,
+			&i.BytesDownloaded,
+			&i.ContentType,
 		); err != nil {
 			return nil, err
 		}
@@ -170,7 +304,7 @@ func (q *Queries) GetDownloadsByStatus(ctx context.Context, state string) ([]Dow
 }
 
 const getPendingDownloadByQueueID = `-- name: GetPendingDownloadByQueueID :one
-SELECT id, queue_id, url, save_path, state, retries FROM downloads
+SELECT id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type FROM downloads
 WHERE queue_id = ? AND state = 'PENDING'
 LIMIT 1
 `
@@ -185,6 +319,18 @@ func (q *Queries) GetPendingDownloadByQueueID(ctx context.Context, queueID int64
 		&i.SavePath,
 		&i.State,
 		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
 	)
 	return i, err
 }
@@ -224,7 +370,7 @@ func (q *Queries) ListDownloadChunks(ctx context.Context) ([]DownloadChunk, erro
 }
 
 const listDownloads = `-- name: ListDownloads :many
-SELECT id, queue_id, url, save_path, state, retries 
+SELECT id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type 
 FROM downloads
 `
 
@@ -244,6 +390,18 @@ func (q *Queries) ListDownloads(ctx context.Context) ([]Download, error) {
 			&i.SavePath,
 			&i.State,
 			&i.Retries,
+			&i.Checksum,
+			&i.Etag,
+			&i.LastModified,
+			&i.ContentLength,
+			&i.Connections,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.TotalSize,
+			&i.BytesDownloaded,
+			&i.ContentType,
 		); err != nil {
 			return nil, err
 		}
@@ -259,18 +417,30 @@ func (q *Queries) ListDownloads(ctx context.Context) ([]Download, error) {
 }
 
 const listDownloadsWithQueueName = `-- name: ListDownloadsWithQueueName :many
-SELECT downloads.id, downloads.queue_id, downloads.url, downloads.save_path, downloads.state, downloads.retries, queues.name as queue_name
+SELECT downloads.id, downloads.queue_id, downloads.url, downloads.save_path, downloads.state, downloads.retries, downloads.checksum, downloads.etag, downloads.last_modified, downloads.content_length, downloads.connections, downloads.last_error, downloads.created_at, downloads.started_at, downloads.completed_at, downloads.total_size, downloads.bytes_downloaded, downloads.content_type, queues.name as queue_name
 FROM downloads JOIN queues on downloads.queue_id = queues.id
 `
 
 type ListDownloadsWithQueueNameRow struct {
-	ID        int64
-	QueueID   int64
-	Url       string
-	SavePath  string
-	State     string
-	Retries   int64
-	QueueName string
+	ID              int64
+	QueueID         int64
+	Url             string
+	SavePath        string
+	State           string
+	Retries         int64
+	Checksum        sql.NullString
+	Etag            sql.NullString
+	LastModified    sql.NullString
+	ContentLength   sql.NullInt64
+	Connections     sql.NullInt64
+	LastError       sql.NullString
+	CreatedAt       sql.NullTime
+	StartedAt       sql.NullTime
+	CompletedAt     sql.NullTime
+	TotalSize       sql.NullInt64
+	BytesDownloaded int64
+	ContentType     sql.NullString
+	QueueName       string
 }
 
 func (q *Queries) ListDownloadsWithQueueName(ctx context.Context) ([]ListDownloadsWithQueueNameRow, error) {
@@ -289,6 +459,18 @@ func (q *Queries) ListDownloadsWithQueueName(ctx context.Context) ([]ListDownloa
 			&i.SavePath,
 			&i.State,
 			&i.Retries,
+			&i.Checksum,
+			&i.Etag,
+			&i.LastModified,
+			&i.ContentLength,
+			&i.Connections,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.TotalSize,
+			&i.BytesDownloaded,
+			&i.ContentType,
 			&i.QueueName,
 		); err != nil {
 			return nil, err
@@ -304,11 +486,77 @@ func (q *Queries) ListDownloadsWithQueueName(ctx context.Context) ([]ListDownloa
 	return items, nil
 }
 
+const setDownloadCompleted = `-- name: SetDownloadCompleted :exec
+UPDATE downloads
+SET completed_at = ?
+WHERE id = ?
+`
+
+type SetDownloadCompletedParams struct {
+	CompletedAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) SetDownloadCompleted(ctx context.Context, arg SetDownloadCompletedParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadCompleted, arg.CompletedAt, arg.ID)
+	return err
+}
+
+const setDownloadContentInfo = `-- name: SetDownloadContentInfo :exec
+UPDATE downloads
+SET total_size = ?, content_type = ?
+WHERE id = ?
+`
+
+type SetDownloadContentInfoParams struct {
+	TotalSize   sql.NullInt64
+	ContentType sql.NullString
+	ID          int64
+}
+
+func (q *Queries) SetDownloadContentInfo(ctx context.Context, arg SetDownloadContentInfoParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadContentInfo, arg.TotalSize, arg.ContentType, arg.ID)
+	return err
+}
+
+const setDownloadLastError = `-- name: SetDownloadLastError :exec
+UPDATE downloads
+SET last_error = ?
+WHERE id = ?
+`
+
+type SetDownloadLastErrorParams struct {
+	LastError sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetDownloadLastError(ctx context.Context, arg SetDownloadLastErrorParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadLastError, arg.LastError, arg.ID)
+	return err
+}
+
+const setDownloadProgress = `-- name: SetDownloadProgress :exec
+UPDATE downloads
+SET bytes_downloaded = ?, total_size = COALESCE(?, total_size)
+WHERE id = ?
+`
+
+type SetDownloadProgressParams struct {
+	BytesDownloaded int64
+	TotalSize       sql.NullInt64
+	ID              int64
+}
+
+func (q *Queries) SetDownloadProgress(ctx context.Context, arg SetDownloadProgressParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadProgress, arg.BytesDownloaded, arg.TotalSize, arg.ID)
+	return err
+}
+
 const setDownloadRetry = `-- name: SetDownloadRetry :one
 UPDATE downloads
 SET retries = ?
 WHERE id = ?
-RETURNING id, queue_id, url, save_path, state, retries
+RETURNING id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type
 `
 
 type SetDownloadRetryParams struct {
@@ -326,15 +574,59 @@ func (q *Queries) SetDownloadRetry(ctx context.Context, arg SetDownloadRetryPara
 		&i.SavePath,
 		&i.State,
 		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
 	)
 	return i, err
 }
 
+const setDownloadSavePath = `-- name: SetDownloadSavePath :exec
+UPDATE downloads
+SET save_path = ?
+WHERE id = ?
+`
+
+type SetDownloadSavePathParams struct {
+	SavePath string
+	ID       int64
+}
+
+func (q *Queries) SetDownloadSavePath(ctx context.Context, arg SetDownloadSavePathParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadSavePath, arg.SavePath, arg.ID)
+	return err
+}
+
+const setDownloadStarted = `-- name: SetDownloadStarted :exec
+UPDATE downloads
+SET started_at = COALESCE(started_at, ?)
+WHERE id = ?
+`
+
+type SetDownloadStartedParams struct {
+	StartedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) SetDownloadStarted(ctx context.Context, arg SetDownloadStartedParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadStarted, arg.StartedAt, arg.ID)
+	return err
+}
+
 const setDownloadState = `-- name: SetDownloadState :one
 UPDATE downloads
 SET state = ?
 WHERE id = ?
-RETURNING id, queue_id, url, save_path, state, retries
+RETURNING id, queue_id, url, save_path, state, retries, checksum, etag, last_modified, content_length, connections, last_error, created_at, started_at, completed_at, total_size, bytes_downloaded, content_type
 `
 
 type SetDownloadStateParams struct {
@@ -352,15 +644,50 @@ func (q *Queries) SetDownloadState(ctx context.Context, arg SetDownloadStatePara
 		&i.SavePath,
 		&i.State,
 		&i.Retries,
+		&i.Checksum,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentLength,
+		&i.Connections,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.TotalSize,
+		&i.BytesDownloaded,
+		&i.ContentType,
 	)
 	return i, err
 }
 
+const setDownloadValidators = `-- name: SetDownloadValidators :exec
+UPDATE downloads
+SET etag = ?, last_modified = ?, content_length = ?
+WHERE id = ?
+`
+
+type SetDownloadValidatorsParams struct {
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	ContentLength sql.NullInt64
+	ID            int64
+}
+
+func (q *Queries) SetDownloadValidators(ctx context.Context, arg SetDownloadValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, setDownloadValidators,
+		arg.Etag,
+		arg.LastModified,
+		arg.ContentLength,
+		arg.ID,
+	)
+	return err
+}
+
 const upsertDownloadChunk = `-- name: UpsertDownloadChunk :one
 INSERT INTO download_chunks (id, range_start, range_end, current_pointer, download_id, single_part)
 VALUES (?, ?, ?, ?, ?, ?)
 ON CONFLICT (id) DO UPDATE
-SET current_pointer = EXCLUDED.current_pointer
+SET current_pointer = EXCLUDED.current_pointer, range_end = EXCLUDED.range_end
 RETURNING id, range_start, range_end, current_pointer, download_id, single_part
 `
 
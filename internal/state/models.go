@@ -6,13 +6,33 @@ import (
 	"database/sql"
 )
 
-type Download struct {
+type Credential struct {
 	ID       int64
-	QueueID  int64
-	Url      string
-	SavePath string
-	State    string
-	Retries  int64
+	Host     string
+	Scheme   string
+	Username string
+	Secret   string
+}
+
+type Download struct {
+	ID              int64
+	QueueID         int64
+	Url             string
+	SavePath        string
+	State           string
+	Retries         int64
+	Checksum        sql.NullString
+	Etag            sql.NullString
+	LastModified    sql.NullString
+	ContentLength   sql.NullInt64
+	Connections     sql.NullInt64
+	LastError       sql.NullString
+	CreatedAt       sql.NullTime
+	StartedAt       sql.NullTime
+	CompletedAt     sql.NullTime
+	TotalSize       sql.NullInt64
+	BytesDownloaded int64
+	ContentType     sql.NullString
 }
 
 type DownloadChunk struct {
@@ -24,14 +44,26 @@ type DownloadChunk struct {
 	SinglePart     bool
 }
 
+type DownloadHeader struct {
+	ID         int64
+	DownloadID int64
+	Name       string
+	Value      string
+}
+
 type Queue struct {
-	ID            int64
-	Name          string
-	Directory     string
-	MaxBandwidth  sql.NullInt64
-	StartDownload TimeValue
-	EndDownload   TimeValue
-	RetryLimit    int64
-	ScheduleMode  bool
-	MaxConcurrent int64
+	ID             int64
+	Name           string
+	Directory      string
+	MaxBandwidth   sql.NullInt64
+	RetryLimit     int64
+	ScheduleMode   bool
+	MaxConcurrent  int64
+	Schedule       Schedule
+	Connections    int64
+	Proxy          sql.NullString
+	NoProxy        sql.NullString
+	CookieFile     sql.NullString
+	TlsInsecure    bool
+	ConflictPolicy string
 }
@@ -0,0 +1,18 @@
+-- name: UpsertCredential :one
+INSERT INTO credentials (host, scheme, username, secret)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (host) DO UPDATE
+SET scheme = EXCLUDED.scheme, username = EXCLUDED.username, secret = EXCLUDED.secret
+RETURNING *;
+
+-- name: GetCredentialByHost :one
+SELECT * FROM credentials
+WHERE host = ?;
+
+-- name: ListCredentials :many
+SELECT * FROM credentials
+ORDER BY host;
+
+-- name: DeleteCredential :exec
+DELETE FROM credentials
+WHERE host = ?;
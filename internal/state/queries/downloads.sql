@@ -1,12 +1,16 @@
 -- name: CreateDownload :one
-INSERT INTO downloads (queue_id, url, save_path, state, retries)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO downloads (queue_id, url, save_path, state, retries, checksum, connections, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetDownload :one
 SELECT * FROM downloads
 WHERE id = ?;
 
+-- name: GetDownloadBySavePath :one
+SELECT * FROM downloads
+WHERE save_path = ?;
+
 -- name: ListDownloadsWithQueueName :many
 SELECT downloads.*, queues.name as queue_name
 FROM downloads JOIN queues on downloads.queue_id = queues.id;
@@ -27,6 +31,41 @@ SET retries = ?
 WHERE id = ?
 RETURNING *;
 
+-- name: SetDownloadSavePath :exec
+UPDATE downloads
+SET save_path = ?
+WHERE id = ?;
+
+-- name: SetDownloadValidators :exec
+UPDATE downloads
+SET etag = ?, last_modified = ?, content_length = ?
+WHERE id = ?;
+
+-- name: SetDownloadStarted :exec
+UPDATE downloads
+SET started_at = COALESCE(started_at, ?)
+WHERE id = ?;
+
+-- name: SetDownloadCompleted :exec
+UPDATE downloads
+SET completed_at = ?
+WHERE id = ?;
+
+-- name: SetDownloadProgress :exec
+UPDATE downloads
+SET bytes_downloaded = ?, total_size = COALESCE(?, total_size)
+WHERE id = ?;
+
+-- name: SetDownloadContentInfo :exec
+UPDATE downloads
+SET total_size = ?, content_type = ?
+WHERE id = ?;
+
+-- name: SetDownloadLastError :exec
+UPDATE downloads
+SET last_error = ?
+WHERE id = ?;
+
 -- name: DeleteDownload :exec
 DELETE FROM downloads
 WHERE id = ?;
@@ -35,7 +74,7 @@ WHERE id = ?;
 INSERT INTO download_chunks (id, range_start, range_end, current_pointer, download_id, single_part)
 VALUES (?, ?, ?, ?, ?, ?)
 ON CONFLICT (id) DO UPDATE
-SET current_pointer = EXCLUDED.current_pointer
+SET current_pointer = EXCLUDED.current_pointer, range_end = EXCLUDED.range_end
 RETURNING *;
 
 -- name: GetDownloadChunk :one
@@ -49,10 +88,23 @@ SELECT * FROM download_chunks;
 DELETE FROM download_chunks
 WHERE id = ?;
 
+-- name: DeleteDownloadChunksByDownloadID :exec
+DELETE FROM download_chunks
+WHERE download_id = ?;
+
 -- name: GetDownloadChunksByDownloadID :many
 SELECT * FROM download_chunks
 WHERE download_id = ?;
 
+-- name: CreateDownloadHeader :exec
+INSERT INTO download_headers (download_id, name, value)
+VALUES (?, ?, ?);
+
+-- name: GetDownloadHeadersByDownloadID :many
+SELECT * FROM download_headers
+WHERE download_id = ?
+ORDER BY id;
+
 -- name: GetPendingDownloadByQueueID :one
 SELECT * FROM downloads
 WHERE queue_id = ? AND state = 'PENDING'
@@ -1,6 +1,6 @@
 -- name: CreateQueue :one
-INSERT INTO queues (name, directory, max_bandwidth, start_download, end_download, retry_limit, max_concurrent, schedule_mode)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO queues (name, directory, max_bandwidth, schedule, retry_limit, max_concurrent, schedule_mode, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetQueue :one
@@ -12,8 +12,9 @@ SELECT * FROM queues;
 
 -- name: UpdateQueue :one
 UPDATE queues
-SET name = ?, max_bandwidth = ?, start_download = ?, end_download = ?,
-retry_limit = ?, max_concurrent = ?, schedule_mode = ?, directory = ?
+SET name = ?, max_bandwidth = ?, schedule = ?,
+retry_limit = ?, max_concurrent = ?, schedule_mode = ?, directory = ?,
+connections = ?, proxy = ?, no_proxy = ?, cookie_file = ?, tls_insecure = ?, conflict_policy = ?
 WHERE id = ?
 RETURNING *;
 
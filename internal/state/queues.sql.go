@@ -8,20 +8,25 @@ import (
 )
 
 const createQueue = `-- name: CreateQueue :one
-INSERT INTO queues (name, directory, max_bandwidth, start_download, end_download, retry_limit, max_concurrent, schedule_mode)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, name, directory, max_bandwidth, start_download, end_download, retry_limit, schedule_mode, max_concurrent
+INSERT INTO queues (name, directory, max_bandwidth, schedule, retry_limit, max_concurrent, schedule_mode, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, name, directory, max_bandwidth, retry_limit, schedule_mode, max_concurrent, schedule, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy
 `
 
 type CreateQueueParams struct {
-	Name          string
-	Directory     string
-	MaxBandwidth  sql.NullInt64
-	StartDownload TimeValue
-	EndDownload   TimeValue
-	RetryLimit    int64
-	MaxConcurrent int64
-	ScheduleMode  bool
+	Name           string
+	Directory      string
+	MaxBandwidth   sql.NullInt64
+	Schedule       Schedule
+	RetryLimit     int64
+	MaxConcurrent  int64
+	ScheduleMode   bool
+	Connections    int64
+	Proxy          sql.NullString
+	NoProxy        sql.NullString
+	CookieFile     sql.NullString
+	TlsInsecure    bool
+	ConflictPolicy string
 }
 
 func (q *Queries) CreateQueue(ctx context.Context, arg CreateQueueParams) (Queue, error) {
@@ -29,11 +34,16 @@ func (q *Queries) CreateQueue(ctx context.Context, arg CreateQueueParams) (Queue
 		arg.Name,
 		arg.Directory,
 		arg.MaxBandwidth,
-		arg.StartDownload,
-		arg.EndDownload,
+		arg.Schedule,
 		arg.RetryLimit,
 		arg.MaxConcurrent,
 		arg.ScheduleMode,
+		arg.Connections,
+		arg.Proxy,
+		arg.NoProxy,
+		arg.CookieFile,
+		arg.TlsInsecure,
+		arg.ConflictPolicy,
 	)
 	var i Queue
 	err := row.Scan(
@@ -41,11 +51,16 @@ func (q *Queries) CreateQueue(ctx context.Context, arg CreateQueueParams) (Queue
 		&i.Name,
 		&i.Directory,
 		&i.MaxBandwidth,
-		&i.StartDownload,
-		&i.EndDownload,
 		&i.RetryLimit,
 		&i.ScheduleMode,
 		&i.MaxConcurrent,
+		&i.Schedule,
+		&i.Connections,
+		&i.Proxy,
+		&i.NoProxy,
+		&i.CookieFile,
+		&i.TlsInsecure,
+		&i.ConflictPolicy,
 	)
 	return i, err
 }
@@ -61,7 +76,7 @@ func (q *Queries) DeleteQueue(ctx context.Context, id int64) error {
 }
 
 const getQueue = `-- name: GetQueue :one
-SELECT id, name, directory, max_bandwidth, start_download, end_download, retry_limit, schedule_mode, max_concurrent FROM queues
+SELECT id, name, directory, max_bandwidth, retry_limit, schedule_mode, max_concurrent, schedule, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy FROM queues
 WHERE id = ?
 `
 
@@ -73,17 +88,22 @@ func (q *Queries) GetQueue(ctx context.Context, id int64) (Queue, error) {
 		&i.Name,
 		&i.Directory,
 		&i.MaxBandwidth,
-		&i.StartDownload,
-		&i.EndDownload,
 		&i.RetryLimit,
 		&i.ScheduleMode,
 		&i.MaxConcurrent,
+		&i.Schedule,
+		&i.Connections,
+		&i.Proxy,
+		&i.NoProxy,
+		&i.CookieFile,
+		&i.TlsInsecure,
+		&i.ConflictPolicy,
 	)
 	return i, err
 }
 
 const listQueues = `-- name: ListQueues :many
-SELECT id, name, directory, max_bandwidth, start_download, end_download, retry_limit, schedule_mode, max_concurrent FROM queues
+SELECT id, name, directory, max_bandwidth, retry_limit, schedule_mode, max_concurrent, schedule, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy FROM queues
 `
 
 func (q *Queries) ListQueues(ctx context.Context) ([]Queue, error) {
@@ -100,11 +120,16 @@ func (q *Queries) ListQueues(ctx context.Context) ([]Queue, error) {
 			&i.Name,
 			&i.Directory,
 			&i.MaxBandwidth,
-			&i.StartDownload,
-			&i.EndDownload,
 			&i.RetryLimit,
 			&i.ScheduleMode,
 			&i.MaxConcurrent,
+			&i.Schedule,
+			&i.Connections,
+			&i.Proxy,
+			&i.NoProxy,
+			&i.CookieFile,
+			&i.TlsInsecure,
+			&i.ConflictPolicy,
 		); err != nil {
 			return nil, err
 		}
@@ -121,34 +146,45 @@ func (q *Queries) ListQueues(ctx context.Context) ([]Queue, error) {
 
 const updateQueue = `-- name: UpdateQueue :one
 UPDATE queues
-SET name = ?, max_bandwidth = ?, start_download = ?, end_download = ?,
-retry_limit = ?, max_concurrent = ?, schedule_mode = ?, directory = ?
+SET name = ?, max_bandwidth = ?, schedule = ?,
+retry_limit = ?, max_concurrent = ?, schedule_mode = ?, directory = ?,
+connections = ?, proxy = ?, no_proxy = ?, cookie_file = ?, tls_insecure = ?, conflict_policy = ?
 WHERE id = ?
-RETURNING id, name, directory, max_bandwidth, start_download, end_download, retry_limit, schedule_mode, max_concurrent
+RETURNING id, name, directory, max_bandwidth, retry_limit, schedule_mode, max_concurrent, schedule, connections, proxy, no_proxy, cookie_file, tls_insecure, conflict_policy
 `
 
 type UpdateQueueParams struct {
-	Name          string
-	MaxBandwidth  sql.NullInt64
-	StartDownload TimeValue
-	EndDownload   TimeValue
-	RetryLimit    int64
-	MaxConcurrent int64
-	ScheduleMode  bool
-	Directory     string
-	ID            int64
+	Name           string
+	MaxBandwidth   sql.NullInt64
+	Schedule       Schedule
+	RetryLimit     int64
+	MaxConcurrent  int64
+	ScheduleMode   bool
+	Directory      string
+	Connections    int64
+	Proxy          sql.NullString
+	NoProxy        sql.NullString
+	CookieFile     sql.NullString
+	TlsInsecure    bool
+	ConflictPolicy string
+	ID             int64
 }
 
 func (q *Queries) UpdateQueue(ctx context.Context, arg UpdateQueueParams) (Queue, error) {
 	row := q.db.QueryRowContext(ctx, updateQueue,
 		arg.Name,
 		arg.MaxBandwidth,
-		arg.StartDownload,
-		arg.EndDownload,
+		arg.Schedule,
 		arg.RetryLimit,
 		arg.MaxConcurrent,
 		arg.ScheduleMode,
 		arg.Directory,
+		arg.Connections,
+		arg.Proxy,
+		arg.NoProxy,
+		arg.CookieFile,
+		arg.TlsInsecure,
+		arg.ConflictPolicy,
 		arg.ID,
 	)
 	var i Queue
@@ -157,11 +193,16 @@ func (q *Queries) UpdateQueue(ctx context.Context, arg UpdateQueueParams) (Queue
 		&i.Name,
 		&i.Directory,
 		&i.MaxBandwidth,
-		&i.StartDownload,
-		&i.EndDownload,
 		&i.RetryLimit,
 		&i.ScheduleMode,
 		&i.MaxConcurrent,
+		&i.Schedule,
+		&i.Connections,
+		&i.Proxy,
+		&i.NoProxy,
+		&i.CookieFile,
+		&i.TlsInsecure,
+		&i.ConflictPolicy,
 	)
 	return i, err
 }
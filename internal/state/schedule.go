@@ -0,0 +1,314 @@
+package state
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const scheduleDateLayout = "2006-01-02 15:04"
+
+type Weekdays uint8
+
+const (
+	NoWeekdays  Weekdays = 0
+	AllWeekdays Weekdays = 1<<7 - 1
+	WorkDays    Weekdays = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	WeekendDays Weekdays = 1<<time.Saturday | 1<<time.Sunday
+)
+
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w&(1<<day) != 0
+}
+
+func (w Weekdays) Toggle(day time.Weekday) Weekdays {
+	return w ^ (1 << day)
+}
+
+func (w Weekdays) String() string {
+	switch w {
+	case AllWeekdays:
+		return "every day"
+	case WorkDays:
+		return "weekdays"
+	case WeekendDays:
+		return "weekends"
+	case NoWeekdays:
+		return "never"
+	}
+
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if w.Has(day) {
+			days = append(days, day.String()[:3])
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+type ScheduleRule struct {
+	Days  Weekdays  `json:"days"`
+	Start TimeValue `json:"start"`
+	End   TimeValue `json:"end"`
+}
+
+func (r ScheduleRule) Validate() error {
+	if r.Days == NoWeekdays {
+		return errors.New("schedule rule must include at least one day")
+	}
+	if err := r.Start.Validate(); err != nil {
+		return fmt.Errorf("start time: %w", err)
+	}
+	if err := r.End.Validate(); err != nil {
+		return fmt.Errorf("end time: %w", err)
+	}
+	return nil
+}
+
+func (r ScheduleRule) Active(now time.Time) bool {
+	at := now.Hour()*3600 + now.Minute()*60 + now.Second()
+	start, end := r.Start.Seconds(), r.End.Seconds()
+	today, yesterday := now.Weekday(), (now.Weekday()+6)%7
+
+	switch {
+	case start == end:
+		return r.Days.Has(today)
+	case start < end:
+		return r.Days.Has(today) && start <= at && at < end
+	default:
+		return (r.Days.Has(today) && at >= start) || (r.Days.Has(yesterday) && at < end)
+	}
+}
+
+func (r ScheduleRule) String() string {
+	if r.Start.Seconds() == r.End.Seconds() {
+		return r.Days.String() + " all day"
+	}
+	return fmt.Sprintf("%s %s-%s", r.Days, r.Start, r.End)
+}
+
+type Schedule struct {
+	Rules     []ScheduleRule `json:"rules,omitempty"`
+	NotBefore *time.Time     `json:"not_before,omitempty"`
+	NotAfter  *time.Time     `json:"not_after,omitempty"`
+	Valid     bool           `json:"-"`
+}
+
+func (s Schedule) Validate() error {
+	var errs []error
+	for i, rule := range s.Rules {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+		}
+	}
+	if s.NotBefore != nil && s.NotAfter != nil && !s.NotAfter.After(*s.NotBefore) {
+		errs = append(errs, errors.New("schedule end date must be after its start date"))
+	}
+	return errors.Join(errs...)
+}
+
+func (s Schedule) Active(now time.Time) bool {
+	if !s.Valid {
+		return true
+	}
+	if s.NotBefore != nil && now.Before(*s.NotBefore) {
+		return false
+	}
+	if s.NotAfter != nil && !now.Before(*s.NotAfter) {
+		return false
+	}
+	if len(s.Rules) == 0 {
+		return true
+	}
+
+	for _, rule := range s.Rules {
+		if rule.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Schedule) String() string {
+	if !s.Valid {
+		return "No Schedule"
+	}
+
+	parts := make([]string, 0, len(s.Rules)+2)
+	for _, rule := range s.Rules {
+		parts = append(parts, rule.String())
+	}
+	if s.NotBefore != nil {
+		parts = append(parts, "from "+s.NotBefore.Local().Format(scheduleDateLayout))
+	}
+	if s.NotAfter != nil {
+		parts = append(parts, "until "+s.NotAfter.Local().Format(scheduleDateLayout))
+	}
+	if len(parts) == 0 {
+		return "always"
+	}
+	return strings.Join(parts, ", ")
+}
+
+type scheduleJSON Schedule
+
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	if !s.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(scheduleJSON(s))
+}
+
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = Schedule{Valid: false}
+		return nil
+	}
+
+	var schedule scheduleJSON
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return err
+	}
+	schedule.Valid = true
+
+	*s = Schedule(schedule)
+	return nil
+}
+
+func ParseScheduleDate(value string) (time.Time, error) {
+	return time.ParseInLocation(scheduleDateLayout, strings.TrimSpace(value), time.Local)
+}
+
+func FormatScheduleDate(value time.Time) string {
+	return value.Local().Format(scheduleDateLayout)
+}
+
+func (s *Schedule) Scan(value interface{}) error {
+	if value == nil {
+		*s = Schedule{Valid: false}
+		return nil
+	}
+
+	var data []byte
+
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported Scan, storing %T into Schedule", value)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	return nil
+}
+
+func (s Schedule) Value() (driver.Value, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func ParseWeekdays(value string) (Weekdays, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "every day", "everyday", "daily":
+		return AllWeekdays, nil
+	case "weekdays":
+		return WorkDays, nil
+	case "weekends":
+		return WeekendDays, nil
+	}
+
+	var days Weekdays
+	for _, part := range strings.Split(strings.ToLower(value), ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+
+		first, ok := weekdayNames[from]
+		if !ok {
+			return NoWeekdays, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return NoWeekdays, fmt.Errorf("unknown day %q", to)
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days |= 1 << day
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func ParseScheduleRule(value string) (ScheduleRule, error) {
+	value = strings.TrimSpace(value)
+
+	if days, ok := strings.CutSuffix(value, " all day"); ok {
+		weekdays, err := ParseWeekdays(days)
+		if err != nil {
+			return ScheduleRule{}, err
+		}
+		return ScheduleRule{Days: weekdays, Start: TimeValue{Valid: true}, End: TimeValue{Valid: true}}, nil
+	}
+
+	idx := strings.LastIndex(value, " ")
+	if idx < 0 {
+		return ScheduleRule{}, fmt.Errorf("invalid schedule rule %q, expected \"<days> HH:MM-HH:MM\" or \"<days> all day\"", value)
+	}
+
+	weekdays, err := ParseWeekdays(value[:idx])
+	if err != nil {
+		return ScheduleRule{}, err
+	}
+
+	start, end, ok := strings.Cut(value[idx+1:], "-")
+	if !ok {
+		return ScheduleRule{}, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", value[idx+1:])
+	}
+
+	rule := ScheduleRule{Days: weekdays}
+	if rule.Start, err = parseClock(start); err != nil {
+		return ScheduleRule{}, err
+	}
+	if rule.End, err = parseClock(end); err != nil {
+		return ScheduleRule{}, err
+	}
+	return rule, rule.Validate()
+}
+
+func parseClock(value string) (TimeValue, error) {
+	if strings.Count(value, ":") == 1 {
+		value += ":00"
+	}
+
+	var t TimeValue
+	if err := t.Scan(value); err != nil {
+		return TimeValue{}, err
+	}
+	return t, nil
+}
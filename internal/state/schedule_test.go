@@ -0,0 +1,260 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func at(day time.Weekday, clock string) time.Time {
+	base := time.Date(2024, time.January, 7, 0, 0, 0, 0, time.Local)
+	offset, err := time.Parse("15:04", clock)
+	if err != nil {
+		panic(err)
+	}
+	return base.AddDate(0, 0, int(day)).Add(time.Duration(offset.Hour())*time.Hour + time.Duration(offset.Minute())*time.Minute)
+}
+
+func clockValue(hour, minute int) TimeValue {
+	return TimeValue{Hour: hour, Minute: minute, Valid: true}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Weekdays
+		wantErr bool
+	}{
+		{value: "every day", want: AllWeekdays},
+		{value: "Daily", want: AllWeekdays},
+		{value: " weekdays ", want: WorkDays},
+		{value: "weekends", want: WeekendDays},
+		{value: "mon", want: 1 << time.Monday},
+		{value: "mon,wed, fri", want: 1<<time.Monday | 1<<time.Wednesday | 1<<time.Friday},
+		{value: "mon-fri", want: WorkDays},
+		{value: "sat-sun", want: WeekendDays},
+		{value: "fri-mon", want: 1<<time.Friday | WeekendDays | 1<<time.Monday},
+		{value: "TUE-tue", want: 1 << time.Tuesday},
+		{value: "monday", wantErr: true},
+		{value: "mon-xyz", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseWeekdays(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWeekdays(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseWeekdays(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeekdaysString(t *testing.T) {
+	tests := []struct {
+		days Weekdays
+		want string
+	}{
+		{days: AllWeekdays, want: "every day"},
+		{days: WorkDays, want: "weekdays"},
+		{days: WeekendDays, want: "weekends"},
+		{days: NoWeekdays, want: "never"},
+		{days: 1<<time.Sunday | 1<<time.Wednesday, want: "Sun,Wed"},
+		{days: WorkDays.Toggle(time.Friday), want: "Mon,Tue,Wed,Thu"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.days.String(); got != tt.want {
+			t.Errorf("Weekdays(%d).String() = %q, want %q", tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ScheduleRule
+		wantErr bool
+	}{
+		{
+			value: "mon-fri 09:00-17:30",
+			want:  ScheduleRule{Days: WorkDays, Start: clockValue(9, 0), End: clockValue(17, 30)},
+		},
+		{
+			value: "weekends 22:00-06:00",
+			want:  ScheduleRule{Days: WeekendDays, Start: clockValue(22, 0), End: clockValue(6, 0)},
+		},
+		{
+			value: "every day 01:00:30-02:00",
+			want:  ScheduleRule{Days: AllWeekdays, Start: TimeValue{Hour: 1, Second: 30, Valid: true}, End: clockValue(2, 0)},
+		},
+		{
+			value: " sat all day ",
+			want:  ScheduleRule{Days: 1 << time.Saturday, Start: TimeValue{Valid: true}, End: TimeValue{Valid: true}},
+		},
+		{value: "mon-fri", wantErr: true},
+		{value: "mon-fri 09:00", wantErr: true},
+		{value: "mon-fri 25:00-26:00", wantErr: true},
+		{value: "mon-fri 09:60-10:00", wantErr: true},
+		{value: "someday 09:00-10:00", wantErr: true},
+		{value: "someday all day", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseScheduleRule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScheduleRule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseScheduleRule(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleRuleActive(t *testing.T) {
+	office := ScheduleRule{Days: WorkDays, Start: clockValue(9, 0), End: clockValue(17, 0)}
+	overnight := ScheduleRule{Days: 1 << time.Friday, Start: clockValue(22, 0), End: clockValue(6, 0)}
+	allDay := ScheduleRule{Days: WeekendDays, Start: TimeValue{Valid: true}, End: TimeValue{Valid: true}}
+
+	tests := []struct {
+		name string
+		rule ScheduleRule
+		now  time.Time
+		want bool
+	}{
+		{name: "inside window", rule: office, now: at(time.Monday, "12:00"), want: true},
+		{name: "start is inclusive", rule: office, now: at(time.Monday, "09:00"), want: true},
+		{name: "end is exclusive", rule: office, now: at(time.Monday, "17:00"), want: false},
+		{name: "before window", rule: office, now: at(time.Monday, "08:59"), want: false},
+		{name: "wrong day", rule: office, now: at(time.Saturday, "12:00"), want: false},
+		{name: "overnight before midnight", rule: overnight, now: at(time.Friday, "23:00"), want: true},
+		{name: "overnight after midnight", rule: overnight, now: at(time.Saturday, "05:59"), want: true},
+		{name: "overnight ends next morning", rule: overnight, now: at(time.Saturday, "06:00"), want: false},
+		{name: "overnight does not start on other days", rule: overnight, now: at(time.Saturday, "23:00"), want: false},
+		{name: "overnight morning of start day", rule: overnight, now: at(time.Friday, "05:00"), want: false},
+		{name: "all day at midnight", rule: allDay, now: at(time.Sunday, "00:00"), want: true},
+		{name: "all day late", rule: allDay, now: at(time.Saturday, "23:59"), want: true},
+		{name: "all day other day", rule: allDay, now: at(time.Monday, "12:00"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Active(tt.now); got != tt.want {
+				t.Errorf("%s.Active(%s) = %v, want %v", tt.rule, tt.now.Format(time.RFC1123), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	from := at(time.Monday, "10:00")
+	until := at(time.Wednesday, "10:00")
+	office := ScheduleRule{Days: WorkDays, Start: clockValue(9, 0), End: clockValue(17, 0)}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		now      time.Time
+		want     bool
+	}{
+		{name: "no schedule", schedule: Schedule{}, now: at(time.Sunday, "03:00"), want: true},
+		{name: "no rules", schedule: Schedule{Valid: true}, now: at(time.Sunday, "03:00"), want: true},
+		{name: "rule matches", schedule: Schedule{Rules: []ScheduleRule{office}, Valid: true}, now: at(time.Tuesday, "09:30"), want: true},
+		{name: "rule does not match", schedule: Schedule{Rules: []ScheduleRule{office}, Valid: true}, now: at(time.Tuesday, "18:00"), want: false},
+		{
+			name: "any rule matches",
+			schedule: Schedule{Rules: []ScheduleRule{
+				office,
+				{Days: AllWeekdays, Start: clockValue(23, 0), End: clockValue(1, 0)},
+			}, Valid: true},
+			now:  at(time.Sunday, "00:30"),
+			want: true,
+		},
+		{name: "before start date", schedule: Schedule{NotBefore: &from, Valid: true}, now: from.Add(-time.Minute), want: false},
+		{name: "start date is inclusive", schedule: Schedule{NotBefore: &from, Valid: true}, now: from, want: true},
+		{name: "end date is exclusive", schedule: Schedule{NotAfter: &until, Valid: true}, now: until, want: false},
+		{name: "before end date", schedule: Schedule{NotAfter: &until, Valid: true}, now: until.Add(-time.Second), want: true},
+		{
+			name:     "date range and rules",
+			schedule: Schedule{Rules: []ScheduleRule{office}, NotBefore: &from, NotAfter: &until, Valid: true},
+			now:      at(time.Tuesday, "16:00"),
+			want:     true,
+		},
+		{
+			name:     "rule matches outside date range",
+			schedule: Schedule{Rules: []ScheduleRule{office}, NotBefore: &from, NotAfter: &until, Valid: true},
+			now:      at(time.Monday, "09:30"),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Active(tt.now); got != tt.want {
+				t.Errorf("%s.Active(%s) = %v, want %v", tt.schedule, tt.now.Format(time.RFC1123), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	from := at(time.Monday, "10:00")
+	before := from.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{name: "empty", schedule: Schedule{Valid: true}},
+		{name: "valid rule", schedule: Schedule{Rules: []ScheduleRule{{Days: WorkDays, Start: clockValue(9, 0), End: clockValue(17, 0)}}, Valid: true}},
+		{name: "rule without days", schedule: Schedule{Rules: []ScheduleRule{{Start: clockValue(9, 0), End: clockValue(17, 0)}}, Valid: true}, wantErr: true},
+		{name: "rule with invalid time", schedule: Schedule{Rules: []ScheduleRule{{Days: WorkDays, Start: TimeValue{Hour: 24, Valid: true}}}, Valid: true}, wantErr: true},
+		{name: "end after start", schedule: Schedule{NotBefore: &before, NotAfter: &from, Valid: true}},
+		{name: "end before start", schedule: Schedule{NotBefore: &from, NotAfter: &before, Valid: true}, wantErr: true},
+		{name: "end equals start", schedule: Schedule{NotBefore: &from, NotAfter: &from, Valid: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleJSON(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	schedule := Schedule{
+		Rules:     []ScheduleRule{{Days: WeekendDays, Start: clockValue(22, 0), End: clockValue(6, 0)}},
+		NotBefore: &from,
+		Valid:     true,
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded Schedule
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !decoded.Valid || len(decoded.Rules) != 1 || decoded.Rules[0] != schedule.Rules[0] || !decoded.NotBefore.Equal(from) {
+		t.Errorf("round trip = %+v, want %+v", decoded, schedule)
+	}
+
+	if data, err := json.Marshal(Schedule{}); err != nil || string(data) != "null" {
+		t.Errorf("Marshal(no schedule) = %s, %v, want null", data, err)
+	}
+	if err := json.Unmarshal([]byte("null"), &decoded); err != nil || decoded.Valid {
+		t.Errorf("Unmarshal(null) = %+v, %v, want an invalid schedule", decoded, err)
+	}
+}
@@ -0,0 +1,9 @@
+ALTER TABLE queues ADD COLUMN start_download TEXT;
+
+ALTER TABLE queues ADD COLUMN end_download TEXT;
+
+UPDATE queues
+SET start_download = json_extract(schedule, '$.rules[0].start'),
+    end_download = json_extract(schedule, '$.rules[0].end');
+
+ALTER TABLE queues DROP COLUMN schedule;
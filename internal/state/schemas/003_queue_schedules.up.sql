@@ -0,0 +1,11 @@
+ALTER TABLE queues ADD COLUMN schedule TEXT; -- JSON encoded schedule rules and date range
+
+UPDATE queues
+SET schedule = json_object(
+    'rules', json_array(json_object('days', 127, 'start', start_download, 'end', end_download))
+)
+WHERE start_download IS NOT NULL AND end_download IS NOT NULL;
+
+ALTER TABLE queues DROP COLUMN start_download;
+
+ALTER TABLE queues DROP COLUMN end_download;
@@ -0,0 +1 @@
+ALTER TABLE downloads ADD COLUMN checksum TEXT; -- Expected checksum as "<algorithm>:<hex digest>"
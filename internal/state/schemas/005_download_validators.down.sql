@@ -0,0 +1,3 @@
+ALTER TABLE downloads DROP COLUMN content_length;
+ALTER TABLE downloads DROP COLUMN last_modified;
+ALTER TABLE downloads DROP COLUMN etag;
@@ -0,0 +1,3 @@
+ALTER TABLE downloads ADD COLUMN etag TEXT; -- ETag reported by the server when the download was probed
+ALTER TABLE downloads ADD COLUMN last_modified TEXT; -- Last-Modified reported by the server when the download was probed
+ALTER TABLE downloads ADD COLUMN content_length INTEGER; -- Content-Length reported by the server when the download was probed
@@ -0,0 +1,3 @@
+ALTER TABLE downloads DROP COLUMN connections;
+
+ALTER TABLE queues DROP COLUMN connections;
@@ -0,0 +1,3 @@
+ALTER TABLE queues ADD COLUMN connections INTEGER NOT NULL DEFAULT 10; -- Parallel connections used by each download of the queue
+
+ALTER TABLE downloads ADD COLUMN connections INTEGER; -- Overrides the queue connection count when set
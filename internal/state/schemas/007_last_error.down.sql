@@ -0,0 +1 @@
+ALTER TABLE downloads DROP COLUMN last_error;
@@ -0,0 +1 @@
+ALTER TABLE downloads ADD COLUMN last_error TEXT; -- message of the most recent failure
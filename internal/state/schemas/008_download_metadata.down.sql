@@ -0,0 +1,6 @@
+ALTER TABLE downloads DROP COLUMN content_type;
+ALTER TABLE downloads DROP COLUMN bytes_downloaded;
+ALTER TABLE downloads DROP COLUMN total_size;
+ALTER TABLE downloads DROP COLUMN completed_at;
+ALTER TABLE downloads DROP COLUMN started_at;
+ALTER TABLE downloads DROP COLUMN created_at;
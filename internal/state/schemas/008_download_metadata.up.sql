@@ -0,0 +1,8 @@
+ALTER TABLE downloads ADD COLUMN created_at TIMESTAMP; -- when the download was added
+ALTER TABLE downloads ADD COLUMN started_at TIMESTAMP; -- when the download first started transferring
+ALTER TABLE downloads ADD COLUMN completed_at TIMESTAMP; -- when the download completed
+ALTER TABLE downloads ADD COLUMN total_size INTEGER; -- size of the file, NULL while unknown
+ALTER TABLE downloads ADD COLUMN bytes_downloaded INTEGER NOT NULL DEFAULT 0; -- bytes received as of the last progress report
+ALTER TABLE downloads ADD COLUMN content_type TEXT; -- Content-Type reported by the server
+
+UPDATE downloads SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE queues DROP COLUMN no_proxy;
+ALTER TABLE queues DROP COLUMN proxy;
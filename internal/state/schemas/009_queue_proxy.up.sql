@@ -0,0 +1,2 @@
+ALTER TABLE queues ADD COLUMN proxy TEXT; -- Proxy URL used by the queue's downloads, overrides the global proxy when set
+ALTER TABLE queues ADD COLUMN no_proxy TEXT; -- Comma separated hosts that bypass the queue proxy
@@ -0,0 +1,3 @@
+DROP INDEX download_headers_download_id;
+
+DROP TABLE download_headers;
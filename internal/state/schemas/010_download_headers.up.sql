@@ -0,0 +1,10 @@
+CREATE TABLE download_headers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    download_id INTEGER NOT NULL, -- Foreign key to the downloads table
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+
+    FOREIGN KEY (download_id) REFERENCES downloads(id) ON DELETE CASCADE
+);
+
+CREATE INDEX download_headers_download_id ON download_headers (download_id);
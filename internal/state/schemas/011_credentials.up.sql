@@ -0,0 +1,7 @@
+CREATE TABLE credentials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host TEXT NOT NULL UNIQUE, -- Host, optionally with a port, the credentials are sent to
+    scheme TEXT NOT NULL, -- basic, digest or bearer
+    username TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL -- Password, or the token for bearer authentication
+);
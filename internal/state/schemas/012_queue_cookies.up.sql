@@ -0,0 +1 @@
+ALTER TABLE queues ADD COLUMN cookie_file TEXT; -- Netscape cookies.txt file used by the queue's downloads, overrides the global cookie file when set
@@ -0,0 +1 @@
+ALTER TABLE queues ADD COLUMN tls_insecure BOOLEAN NOT NULL DEFAULT FALSE; -- Skip TLS certificate verification for the queue's downloads, unsafe and only for testing
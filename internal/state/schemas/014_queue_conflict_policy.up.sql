@@ -0,0 +1 @@
+ALTER TABLE queues ADD COLUMN conflict_policy TEXT NOT NULL DEFAULT 'rename'; -- What to do when the save path is taken: rename, overwrite, skip or resume
@@ -27,9 +27,11 @@ func SetupDatabase(ctx context.Context) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to determine app data directory: %w", err)
 	}
 
-	dbPath := filepath.Join(dataDir, databaseFileName)
+	return OpenDatabase(ctx, filepath.Join(dataDir, databaseFileName))
+}
 
-	dsn := fmt.Sprintf("file:%s?_foreign_keys=on", dbPath)
+func OpenDatabase(ctx context.Context, dbPath string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", dbPath)
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -29,6 +29,10 @@ func (t TimeValue) Validate() error {
 	return nil
 }
 
+func (t TimeValue) Seconds() int {
+	return t.Hour*3600 + t.Minute*60 + t.Second
+}
+
 func (t TimeValue) String() string {
 	return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
 }
@@ -103,3 +107,14 @@ func (t TimeValue) Value() (driver.Value, error) {
 	}
 	return t.String(), nil
 }
+
+func (t TimeValue) MarshalText() ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(t.String()), nil
+}
+
+func (t *TimeValue) UnmarshalText(text []byte) error {
+	return t.Scan(string(text))
+}
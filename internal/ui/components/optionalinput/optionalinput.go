@@ -14,14 +14,14 @@ var (
 	checkboxStyle = lipgloss.NewStyle().Bold(true)
 )
 
-type OptionalInput[T comparable] struct {
+type OptionalInput[T any] struct {
 	input      types.Input[T]
 	enabled    bool
 	focused    bool
 	toggleKeys keyMap
 }
 
-func New[T comparable](input types.Input[T]) *OptionalInput[T] {
+func New[T any](input types.Input[T]) *OptionalInput[T] {
 	return &OptionalInput[T]{
 		input:      input,
 		enabled:    false,
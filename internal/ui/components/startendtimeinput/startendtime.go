@@ -3,9 +3,12 @@ package startendtimeinput
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/samber/lo"
@@ -20,116 +23,250 @@ type focusedInput int
 const (
 	startTimeFocused focusedInput = iota
 	endTimeFocused
+	daysFocused
+	notBeforeFocused
+	notAfterFocused
+
+	totalFocusable
 )
 
-type StartEndTime lo.Tuple2[state.TimeValue, state.TimeValue]
+var (
+	labelStyle       = lipgloss.NewStyle().Bold(true)
+	dayStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	selectedDayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+	dayCursorStyle   = lipgloss.NewStyle().Underline(true)
+)
 
 type KeyMap struct {
-	Next key.Binding
-	Prev key.Binding
+	Next       key.Binding
+	Prev       key.Binding
+	NextRule   key.Binding
+	PrevRule   key.Binding
+	AddRule    key.Binding
+	RemoveRule key.Binding
+	NextDay    key.Binding
+	PrevDay    key.Binding
+	ToggleDay  key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
 		Next: key.NewBinding(
 			key.WithKeys("l"),
-			key.WithHelp("l", "next time input"),
+			key.WithHelp("l", "next schedule input"),
 		),
 		Prev: key.NewBinding(
 			key.WithKeys("h"),
-			key.WithHelp("h", "previous time input"),
+			key.WithHelp("h", "previous schedule input"),
+		),
+		NextRule: key.NewBinding(
+			key.WithKeys("j"),
+			key.WithHelp("j", "next rule"),
+		),
+		PrevRule: key.NewBinding(
+			key.WithKeys("k"),
+			key.WithHelp("k", "previous rule"),
+		),
+		AddRule: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "add rule"),
+		),
+		RemoveRule: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "remove rule"),
+		),
+		NextDay: key.NewBinding(
+			key.WithKeys("right"),
+			key.WithHelp("→", "next day"),
+		),
+		PrevDay: key.NewBinding(
+			key.WithKeys("left"),
+			key.WithHelp("←", "previous day"),
+		),
+		ToggleDay: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "toggle day"),
 		),
 	}
 }
 
-type Model struct {
+type rule struct {
+	days      state.Weekdays
 	startTime *timeinput.Model
 	endTime   *timeinput.Model
+}
+
+func newRule() *rule {
+	startTime := timeinput.New()
+	endTime := timeinput.New()
+
+	startTime.Blur()
+	endTime.Blur()
+
+	return &rule{
+		days:      state.AllWeekdays,
+		startTime: startTime,
+		endTime:   endTime,
+	}
+}
+
+func (r *rule) value() state.ScheduleRule {
+	return state.ScheduleRule{
+		Days:  r.days,
+		Start: r.startTime.Value(),
+		End:   r.endTime.Value(),
+	}
+}
+
+type Model struct {
+	rules     []*rule
+	current   int
+	dayCursor time.Weekday
+	notBefore *textinput.Model
+	notAfter  *textinput.Model
 	focused   focusedInput
 	keyMap    KeyMap
 }
 
+func (m *Model) currentRule() *rule {
+	return m.rules[m.current]
+}
+
 func (m *Model) Blur() {
-	switch m.focused {
-	case startTimeFocused:
-		m.startTime.Blur()
-	case endTimeFocused:
-		m.endTime.Blur()
+	for _, r := range m.rules {
+		r.startTime.Blur()
+		r.endTime.Blur()
 	}
-
+	m.notBefore.Blur()
+	m.notAfter.Blur()
 }
 
 func (m *Model) Error() error {
-	return errors.Join(m.startTimeError(), m.endTimeError())
-}
+	var errs []error
 
-func (m Model) startTimeError() error {
-	if m.startTime.Error() != nil {
-		return fmt.Errorf("start time: %w", m.startTime.Error())
+	for i, r := range m.rules {
+		if err := r.startTime.Error(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d start time: %w", i+1, err))
+		}
+		if err := r.endTime.Error(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d end time: %w", i+1, err))
+		}
 	}
-	return nil
-}
 
-func (m Model) endTimeError() error {
-	if m.endTime.Error() != nil {
-		return fmt.Errorf("end time: %w", m.endTime.Error())
+	if m.notBefore.Err != nil {
+		errs = append(errs, fmt.Errorf("starts on: %w", m.notBefore.Err))
 	}
-	return nil
+	if m.notAfter.Err != nil {
+		errs = append(errs, fmt.Errorf("ends on: %w", m.notAfter.Err))
+	}
+
+	if len(errs) == 0 {
+		return m.Value().Validate()
+	}
+
+	return errors.Join(errs...)
 }
 
 func (m *Model) Focus() tea.Cmd {
+	m.Blur()
 	m.focused = startTimeFocused
 
-	return m.startTime.Focus()
+	return m.currentRule().startTime.Focus()
 }
 
-func (m *Model) FullHelp() [][]key.Binding {
-	var bindings [][]key.Binding
-
-	bindings = append(bindings, []key.Binding{m.keyMap.Next, m.keyMap.Prev})
+func (m *Model) focusCurrent() tea.Cmd {
+	m.Blur()
 
 	switch m.focused {
 	case startTimeFocused:
-		bindings = append(bindings, m.startTime.FullHelp()...)
+		return m.currentRule().startTime.Focus()
 	case endTimeFocused:
-		bindings = append(bindings, m.endTime.FullHelp()...)
+		return m.currentRule().endTime.Focus()
+	case notBeforeFocused:
+		return m.notBefore.Focus()
+	case notAfterFocused:
+		return m.notAfter.Focus()
 	}
 
-	return bindings
-}
-
-func (m *Model) Init() tea.Cmd {
-	return tea.Batch(
-		m.startTime.Init(),
-		m.endTime.Init(),
-	)
+	return nil
 }
 
-func (m *Model) SetValue(value StartEndTime) error {
-	startErr := m.startTime.SetValue(value.A)
-	endErr := m.endTime.SetValue(value.B)
+func (m *Model) FullHelp() [][]key.Binding {
+	bindings := [][]key.Binding{
+		{m.keyMap.Next, m.keyMap.Prev},
+		{m.keyMap.NextRule, m.keyMap.PrevRule, m.keyMap.AddRule, m.keyMap.RemoveRule},
+	}
 
-	if startErr != nil || endErr != nil {
-		return errors.Join(startErr, endErr)
+	switch m.focused {
+	case startTimeFocused:
+		bindings = append(bindings, m.currentRule().startTime.FullHelp()...)
+	case endTimeFocused:
+		bindings = append(bindings, m.currentRule().endTime.FullHelp()...)
+	case daysFocused:
+		bindings = append(bindings, []key.Binding{m.keyMap.PrevDay, m.keyMap.NextDay, m.keyMap.ToggleDay})
 	}
 
-	return nil
+	return bindings
 }
 
 func (m *Model) ShortHelp() []key.Binding {
-	bindings := []key.Binding{m.keyMap.Next, m.keyMap.Prev}
+	bindings := []key.Binding{m.keyMap.Next, m.keyMap.Prev, m.keyMap.NextRule, m.keyMap.AddRule, m.keyMap.RemoveRule}
 
 	switch m.focused {
 	case startTimeFocused:
-		bindings = append(bindings, m.startTime.ShortHelp()...)
+		bindings = append(bindings, m.currentRule().startTime.ShortHelp()...)
 	case endTimeFocused:
-		bindings = append(bindings, m.endTime.ShortHelp()...)
+		bindings = append(bindings, m.currentRule().endTime.ShortHelp()...)
+	case daysFocused:
+		bindings = append(bindings, m.keyMap.PrevDay, m.keyMap.NextDay, m.keyMap.ToggleDay)
 	}
 
 	return bindings
 }
 
-func (m *Model) Update(msg tea.Msg) (types.Input[StartEndTime], tea.Cmd) {
+func (m *Model) Init() tea.Cmd {
+	return tea.Batch(lo.Map(m.rules, func(r *rule, _ int) tea.Cmd {
+		return tea.Batch(r.startTime.Init(), r.endTime.Init())
+	})...)
+}
+
+func (m *Model) SetValue(value state.Schedule) error {
+	rules := make([]*rule, 0, len(value.Rules))
+
+	for _, scheduleRule := range value.Rules {
+		r := newRule()
+		r.days = scheduleRule.Days
+
+		startErr := r.startTime.SetValue(scheduleRule.Start)
+		endErr := r.endTime.SetValue(scheduleRule.End)
+		if startErr != nil || endErr != nil {
+			return errors.Join(startErr, endErr)
+		}
+
+		rules = append(rules, r)
+	}
+
+	if len(rules) == 0 {
+		rules = append(rules, newRule())
+	}
+
+	m.rules = rules
+	m.current = 0
+
+	m.notBefore.SetValue("")
+	if value.NotBefore != nil {
+		m.notBefore.SetValue(state.FormatScheduleDate(*value.NotBefore))
+	}
+
+	m.notAfter.SetValue("")
+	if value.NotAfter != nil {
+		m.notAfter.SetValue(state.FormatScheduleDate(*value.NotAfter))
+	}
+
+	return nil
+}
+
+func (m *Model) Update(msg tea.Msg) (types.Input[state.Schedule], tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -137,30 +274,58 @@ func (m *Model) Update(msg tea.Msg) (types.Input[StartEndTime], tea.Cmd) {
 
 		switch {
 		case key.Matches(msg, m.keyMap.Next):
-			if m.focused == startTimeFocused {
-				m.startTime.Blur()
-				m.focused = endTimeFocused
-
-				return m, m.endTime.Focus()
-			}
+			m.focused = (m.focused + 1) % totalFocusable
+			return m, m.focusCurrent()
 		case key.Matches(msg, m.keyMap.Prev):
-			if m.focused == endTimeFocused {
-				m.endTime.Blur()
-				m.focused = startTimeFocused
-
-				return m, m.startTime.Focus()
+			m.focused = (m.focused - 1 + totalFocusable) % totalFocusable
+			return m, m.focusCurrent()
+		case key.Matches(msg, m.keyMap.NextRule):
+			m.current = (m.current + 1) % len(m.rules)
+			return m, m.focusCurrent()
+		case key.Matches(msg, m.keyMap.PrevRule):
+			m.current = (m.current - 1 + len(m.rules)) % len(m.rules)
+			return m, m.focusCurrent()
+		case key.Matches(msg, m.keyMap.AddRule):
+			m.rules = append(m.rules, newRule())
+			m.current = len(m.rules) - 1
+			m.focused = startTimeFocused
+			return m, m.focusCurrent()
+		case key.Matches(msg, m.keyMap.RemoveRule):
+			if len(m.rules) > 1 {
+				m.rules = append(m.rules[:m.current], m.rules[m.current+1:]...)
+				m.current = min(m.current, len(m.rules)-1)
 			}
+			return m, m.focusCurrent()
 		}
 
 		switch m.focused {
 		case startTimeFocused:
-			updatedInput, cmd := m.startTime.Update(msg)
-			m.startTime = updatedInput.(*timeinput.Model)
+			updatedInput, cmd := m.currentRule().startTime.Update(msg)
+			m.currentRule().startTime = updatedInput.(*timeinput.Model)
 
 			cmds = append(cmds, cmd)
 		case endTimeFocused:
-			updatedInput, cmd := m.endTime.Update(msg)
-			m.endTime = updatedInput.(*timeinput.Model)
+			updatedInput, cmd := m.currentRule().endTime.Update(msg)
+			m.currentRule().endTime = updatedInput.(*timeinput.Model)
+
+			cmds = append(cmds, cmd)
+		case daysFocused:
+			switch {
+			case key.Matches(msg, m.keyMap.NextDay):
+				m.dayCursor = (m.dayCursor + 1) % 7
+			case key.Matches(msg, m.keyMap.PrevDay):
+				m.dayCursor = (m.dayCursor + 6) % 7
+			case key.Matches(msg, m.keyMap.ToggleDay):
+				m.currentRule().days = m.currentRule().days.Toggle(m.dayCursor)
+			}
+		case notBeforeFocused:
+			updatedInput, cmd := m.notBefore.Update(msg)
+			m.notBefore = &updatedInput
+
+			cmds = append(cmds, cmd)
+		case notAfterFocused:
+			updatedInput, cmd := m.notAfter.Update(msg)
+			m.notAfter = &updatedInput
 
 			cmds = append(cmds, cmd)
 		}
@@ -168,66 +333,129 @@ func (m *Model) Update(msg tea.Msg) (types.Input[StartEndTime], tea.Cmd) {
 
 		switch m.focused {
 		case startTimeFocused:
-			updatedInput, cmd := m.startTime.Update(msg)
-			m.startTime = updatedInput.(*timeinput.Model)
+			updatedInput, cmd := m.currentRule().startTime.Update(msg)
+			m.currentRule().startTime = updatedInput.(*timeinput.Model)
 
 			cmds = append(cmds, cmd)
 		case endTimeFocused:
-			updatedInput, cmd := m.endTime.Update(msg)
-			m.endTime = updatedInput.(*timeinput.Model)
+			updatedInput, cmd := m.currentRule().endTime.Update(msg)
+			m.currentRule().endTime = updatedInput.(*timeinput.Model)
+
+			cmds = append(cmds, cmd)
+		case notBeforeFocused:
+			updatedInput, cmd := m.notBefore.Update(msg)
+			m.notBefore = &updatedInput
+
+			cmds = append(cmds, cmd)
+		case notAfterFocused:
+			updatedInput, cmd := m.notAfter.Update(msg)
+			m.notAfter = &updatedInput
 
 			cmds = append(cmds, cmd)
 		}
 	default:
 
-		startInput, startCmd := m.startTime.Update(msg)
-		m.startTime = startInput.(*timeinput.Model)
+		for _, r := range m.rules {
+			startInput, startCmd := r.startTime.Update(msg)
+			r.startTime = startInput.(*timeinput.Model)
 
-		endInput, endCmd := m.endTime.Update(msg)
-		m.endTime = endInput.(*timeinput.Model)
+			endInput, endCmd := r.endTime.Update(msg)
+			r.endTime = endInput.(*timeinput.Model)
 
-		cmds = append(cmds, startCmd, endCmd)
+			cmds = append(cmds, startCmd, endCmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m *Model) Value() StartEndTime {
-	return StartEndTime{
-		A: m.startTime.Value(),
-		B: m.endTime.Value(),
+func (m *Model) Value() state.Schedule {
+	schedule := state.Schedule{
+		Rules: lo.Map(m.rules, func(r *rule, _ int) state.ScheduleRule {
+			return r.value()
+		}),
+		Valid: true,
+	}
+
+	if notBefore, err := state.ParseScheduleDate(m.notBefore.Value()); err == nil {
+		schedule.NotBefore = &notBefore
+	}
+
+	if notAfter, err := state.ParseScheduleDate(m.notAfter.Value()); err == nil {
+		schedule.NotAfter = &notAfter
+	}
+
+	return schedule
+}
+
+func (m *Model) daysView() string {
+	days := m.currentRule().days
+
+	var sb strings.Builder
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		style := dayStyle
+		if days.Has(day) {
+			style = selectedDayStyle
+		}
+		if m.focused == daysFocused && day == m.dayCursor {
+			style = style.Inherit(dayCursorStyle)
+		}
+
+		sb.WriteString(style.Render(day.String()[:3]))
+		sb.WriteString(" ")
 	}
+
+	return sb.String()
 }
 
 func (m *Model) View() string {
-	startLabel := lipgloss.NewStyle().
-		Bold(true).
-		Render("Start Time: ")
+	r := m.currentRule()
 
-	endLabel := lipgloss.NewStyle().
-		Bold(true).
-		Render("End Time: ")
+	ruleHeader := labelStyle.Render(fmt.Sprintf("Rule %d of %d", m.current+1, len(m.rules)))
 
-	startTimeView := startLabel + m.startTime.View()
-	endTimeView := endLabel + m.endTime.View()
+	timesView := lipgloss.JoinHorizontal(
+		lipgloss.Center,
+		labelStyle.Render("Start Time: ")+r.startTime.View(),
+		"   ",
+		labelStyle.Render("End Time: ")+r.endTime.View(),
+	)
 
-	return lipgloss.JoinHorizontal(
+	daysView := labelStyle.Render("Days: ") + m.daysView()
+
+	dateRangeView := lipgloss.JoinHorizontal(
 		lipgloss.Center,
-		startTimeView,
-		"   ", 
-		endTimeView,
+		labelStyle.Render("Starts On: ")+m.notBefore.View(),
+		"   ",
+		labelStyle.Render("Ends On: ")+m.notAfter.View(),
 	)
+
+	return lipgloss.JoinVertical(lipgloss.Left, ruleHeader, timesView, daysView, dateRangeView)
 }
 
-func New() types.Input[StartEndTime] {
-	startTimeModel := timeinput.New()
-	endTimeModel := timeinput.New()
+func newDateInput() *textinput.Model {
+	dateInput := textinput.New()
+	dateInput.Placeholder = "YYYY-MM-DD HH:MM"
+	dateInput.CharLimit = 16
+	dateInput.Width = 16
+	dateInput.Validate = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if _, err := state.ParseScheduleDate(s); err != nil {
+			return errors.New("date must look like YYYY-MM-DD HH:MM")
+		}
+		return nil
+	}
 
-	endTimeModel.Blur()
+	return &dateInput
+}
 
+func New() types.Input[state.Schedule] {
 	model := &Model{
-		startTime: startTimeModel,
-		endTime:   endTimeModel,
+		rules:     []*rule{newRule()},
+		dayCursor: time.Monday,
+		notBefore: newDateInput(),
+		notAfter:  newDateInput(),
 		focused:   startTimeFocused,
 		keyMap:    DefaultKeyMap(),
 	}
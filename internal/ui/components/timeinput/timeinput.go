@@ -111,7 +111,7 @@ func (m *Model) Value() state.TimeValue {
 	hour, _ := strconv.Atoi(m.hourInput.Value())
 	minute, _ := strconv.Atoi(m.minuteInput.Value())
 	second, _ := strconv.Atoi(m.secondInput.Value())
-	return state.TimeValue{Hour: hour, Minute: minute, Second: second}
+	return state.TimeValue{Hour: hour, Minute: minute, Second: second, Valid: true}
 }
 
 func (m *Model) Focus() tea.Cmd {
@@ -11,7 +11,7 @@ type Focusable interface {
 	Blur()
 }
 
-type Input[T comparable] interface {
+type Input[T any] interface {
 	Init() tea.Cmd
 	Update(tea.Msg) (Input[T], tea.Cmd)
 	View() string
@@ -14,6 +14,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/samber/lo"
 
+	"github.com/computer-technology-team/download-manager.git/internal/downloads"
 	"github.com/computer-technology-team/download-manager.git/internal/events"
 	"github.com/computer-technology-team/download-manager.git/internal/queues"
 	"github.com/computer-technology-team/download-manager.git/internal/state"
@@ -26,13 +27,19 @@ const (
 	url = iota
 	queueName
 	fileName
+	checksum
+	connectionCount
+	requestHeaders
 )
 
+const headerSeparator = "|"
+
 var (
 	ErrURLRequired        = errors.New("URL is required")
 	ErrURLInvalidProtocol = errors.New("URL must start with http:// or https://")
 	ErrURLParseFailed     = errors.New("failed to parse the URL")
 	ErrURLHostEmpty       = errors.New("URL host can not be empty")
+	ErrInvalidConnections = fmt.Errorf("connections must be a number between 1 and %d", downloads.MaxConnections)
 )
 
 type addDownloadFormError struct {
@@ -41,7 +48,7 @@ type addDownloadFormError struct {
 
 type addDownloadFormClear struct{}
 
-func (s addDownloadView) addDownloadCmd(url, fileName string, queueIDStr string) tea.Cmd {
+func (s addDownloadView) addDownloadCmd(url, fileName string, queueIDStr string, checksum string, connectionsStr string, headersStr string) tea.Cmd {
 	return func() tea.Msg {
 		slog.Info("add download", "url", url, "queue_name", queueName, "file_name", fileName)
 
@@ -54,7 +61,27 @@ func (s addDownloadView) addDownloadCmd(url, fileName string, queueIDStr string)
 			}
 		}
 
-		err = s.queueManager.CreateDownload(context.Background(), url, fileName, queueID)
+		var connections int64
+		if connectionsStr != "" {
+			connections, err = strconv.ParseInt(connectionsStr, 10, 64)
+			if err != nil {
+				return addDownloadFormError{error: ErrInvalidConnections}
+			}
+		}
+
+		headers, err := parseHeaderList(headersStr)
+		if err != nil {
+			return addDownloadFormError{error: err}
+		}
+
+		_, err = s.queueManager.CreateDownload(context.Background(), queues.CreateDownloadParams{
+			URL:         url,
+			FileName:    fileName,
+			QueueID:     queueID,
+			Checksum:    checksum,
+			Connections: connections,
+			Headers:     headers,
+		})
 		if err != nil {
 			return addDownloadFormError{error: err}
 		}
@@ -135,14 +162,53 @@ func initialModel(ctx context.Context, queueManager queues.QueueManager) (types.
 	inputsQueueName := listinput.New("Select The Queue", "queue", "queues", queueList)
 
 	inputsFileName := textinput.New()
-	inputsFileName.Placeholder = "Leave empty to use filename from URL"
+	inputsFileName.Placeholder = "Leave empty to use the name suggested by the server"
 	inputsFileName.Width = 50
 	inputsFileName.Prompt = ""
 
-	inputs := make([]types.Input[string], 3)
+	inputsChecksum := textinput.New()
+	inputsChecksum.Placeholder = "Optional, e.g. sha256:<hex digest>"
+	inputsChecksum.Width = 50
+	inputsChecksum.Prompt = ""
+	inputsChecksum.Validate = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := downloads.ParseChecksum(s)
+		return err
+	}
+
+	inputsConnections := textinput.New()
+	inputsConnections.Placeholder = "Leave empty to use the queue setting"
+	inputsConnections.Width = 50
+	inputsConnections.Prompt = ""
+	inputsConnections.Validate = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		connections, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || connections < 1 || connections > downloads.MaxConnections {
+			return ErrInvalidConnections
+		}
+		return nil
+	}
+
+	inputsHeaders := textinput.New()
+	inputsHeaders.Placeholder = "Optional, e.g. Referer: https://example.com | Cookie: session=abc"
+	inputsHeaders.Width = 50
+	inputsHeaders.Prompt = ""
+	inputsHeaders.Validate = func(s string) error {
+		_, err := parseHeaderList(s)
+		return err
+	}
+
+	inputs := make([]types.Input[string], 6)
 	inputs[url] = inputsUrl
 	inputs[queueName] = inputsQueueName
 	inputs[fileName] = inputsFileName
+	inputs[checksum] = inputsChecksum
+	inputs[connectionCount] = inputsConnections
+	inputs[requestHeaders] = inputsHeaders
 
 	return addDownloadView{
 		inputs:  inputs,
@@ -223,6 +289,36 @@ func (m addDownloadView) Update(msg tea.Msg) (types.View, tea.Cmd) {
 			})
 		}
 
+		checksumInput := m.inputs[checksum]
+		err = checksumInput.SetValue("")
+		if err != nil {
+			slog.Error("could not reset checksum in add download form",
+				"error", err)
+			return m, createErrorCmd(types.ErrorMsg{
+				Err: fmt.Errorf("could not reset form"),
+			})
+		}
+
+		connectionsInput := m.inputs[connectionCount]
+		err = connectionsInput.SetValue("")
+		if err != nil {
+			slog.Error("could not reset connections in add download form",
+				"error", err)
+			return m, createErrorCmd(types.ErrorMsg{
+				Err: fmt.Errorf("could not reset form"),
+			})
+		}
+
+		headersInput := m.inputs[requestHeaders]
+		err = headersInput.SetValue("")
+		if err != nil {
+			slog.Error("could not reset headers in add download form",
+				"error", err)
+			return m, createErrorCmd(types.ErrorMsg{
+				Err: fmt.Errorf("could not reset form"),
+			})
+		}
+
 		m.focused = url
 		for i := range m.inputs {
 			m.inputs[i].Blur()
@@ -239,9 +335,25 @@ func (m addDownloadView) Update(msg tea.Msg) (types.View, tea.Cmd) {
 					return m, nil
 				}
 
+				if err := m.inputs[checksum].Error(); err != nil {
+					m.err = err
+					return m, nil
+				}
+
+				if err := m.inputs[connectionCount].Error(); err != nil {
+					m.err = err
+					return m, nil
+				}
+
+				if err := m.inputs[requestHeaders].Error(); err != nil {
+					m.err = err
+					return m, nil
+				}
+
 				m.err = nil
 				return m, m.addDownloadCmd(m.inputs[url].Value(),
-					m.inputs[fileName].Value(), m.inputs[queueName].Value())
+					m.inputs[fileName].Value(), m.inputs[queueName].Value(), m.inputs[checksum].Value(),
+					m.inputs[connectionCount].Value(), m.inputs[requestHeaders].Value())
 			}
 			m.nextInput()
 		case tea.KeyCtrlC, tea.KeyEsc:
@@ -304,6 +416,42 @@ func (m addDownloadView) View() string {
 	}
 	stringBuilder.WriteString("\n\n")
 
+	stringBuilder.WriteString("Checksum: ")
+	if m.focused == checksum {
+		stringBuilder.WriteString("> ")
+	} else {
+		stringBuilder.WriteString("  ")
+	}
+	stringBuilder.WriteString(m.inputs[checksum].View())
+	if err := m.inputs[checksum].Error(); err != nil {
+		stringBuilder.WriteString(" ⚠️ " + err.Error())
+	}
+	stringBuilder.WriteString("\n\n")
+
+	stringBuilder.WriteString("Connections: ")
+	if m.focused == connectionCount {
+		stringBuilder.WriteString("> ")
+	} else {
+		stringBuilder.WriteString("  ")
+	}
+	stringBuilder.WriteString(m.inputs[connectionCount].View())
+	if err := m.inputs[connectionCount].Error(); err != nil {
+		stringBuilder.WriteString(" ⚠️ " + err.Error())
+	}
+	stringBuilder.WriteString("\n\n")
+
+	stringBuilder.WriteString("Headers: ")
+	if m.focused == requestHeaders {
+		stringBuilder.WriteString("> ")
+	} else {
+		stringBuilder.WriteString("  ")
+	}
+	stringBuilder.WriteString(m.inputs[requestHeaders].View())
+	if err := m.inputs[requestHeaders].Error(); err != nil {
+		stringBuilder.WriteString(" ⚠️ " + err.Error())
+	}
+	stringBuilder.WriteString("\n\n")
+
 	if m.err != nil {
 		stringBuilder.WriteString("Error: " + m.err.Error() + "\n\n")
 	}
@@ -321,6 +469,16 @@ func (m *addDownloadView) prevInput() {
 	}
 }
 
+func parseHeaderList(s string) (map[string]string, error) {
+	var lines []string
+	for _, line := range strings.Split(s, headerSeparator) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return downloads.ParseHeaders(lines)
+}
+
 func queueToAddDownloadQueueItem(queue state.Queue) list.Item {
 	return listinput.NewItem(strconv.Itoa(int(queue.ID)), queue.Name, queue.Directory)
 }
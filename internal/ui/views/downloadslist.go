@@ -2,8 +2,11 @@ package views
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
@@ -20,15 +23,23 @@ import (
 var errNoDownloadAvailable = errors.New("no download is available")
 
 var downloadsColumnRatios = []float64{
-	0.45,
-	0.30,
-	0.25,
+	0.22,
+	0.10,
+	0.08,
+	0.18,
+	0.12,
+	0.12,
+	0.18,
 }
 
 var downloadsColumns = []table.Column{
 	{Title: "URL", Width: 10},
 	{Title: "Queue Name", Width: 10},
+	{Title: "Size", Width: 10},
 	{Title: "Progress", Width: 10},
+	{Title: "Added", Width: 10},
+	{Title: "Completed", Width: 10},
+	{Title: "Last Error", Width: 10},
 }
 
 type downloadsListKeyMap struct {
@@ -102,8 +113,34 @@ func (m downloadsListView) handleUpdate(msg events.Event) (types.View, tea.Cmd)
 
 		for i, download := range m.downloads {
 			if download.ID == status.ID {
-				m.downloads[i].State = fmt.Sprintf("%f - %s", status.ProgressPercentage,
-					FormatBytesPerSecond(int64(status.Speed)))
+				m.downloads[i].State = formatProgress(status)
+				m.downloads[i].BytesDownloaded = status.Downloaded
+				m.downloads[i].TotalSize = sql.NullInt64{Int64: status.TotalSize, Valid: status.SizeKnown()}
+			}
+		}
+
+		m.setTableRows()
+
+		return m, nil
+
+	case events.DownloadCompleted:
+		status := msg.Payload.(downloads.DownloadStatus)
+		for i, download := range m.downloads {
+			if download.ID == status.ID {
+				m.downloads[i].BytesDownloaded = status.Downloaded
+				m.downloads[i].CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			}
+		}
+
+		m.setTableRows()
+
+		return m, nil
+
+	case events.DownloadFailed:
+		failed := msg.Payload.(events.DownloadFailedEvent)
+		for i, download := range m.downloads {
+			if download.ID == failed.ID && failed.Error != nil {
+				m.downloads[i].LastError = sql.NullString{String: failed.Error.Error(), Valid: true}
 			}
 		}
 
@@ -269,8 +306,40 @@ func NewDownloadsList(ctx context.Context, queueManager queues.QueueManager) (ty
 	return dv, nil
 }
 
+func formatProgress(status downloads.DownloadStatus) string {
+	speed := FormatBytesPerSecond(int64(status.Speed))
+	if !status.SizeKnown() {
+		return fmt.Sprintf("%s %s of unknown size - %s", indeterminateIndicator(status.Downloaded), FormatBytes(status.Downloaded), speed)
+	}
+	return fmt.Sprintf("%f - %s", status.ProgressPercentage, speed)
+}
+
+func indeterminateIndicator(downloaded int64) string {
+	const width = 6
+	position := int(downloaded/(1<<16)) % (2*width - 2)
+	if position >= width {
+		position = 2*width - 2 - position
+	}
+	return "[" + strings.Repeat(" ", position) + "=" + strings.Repeat(" ", width-1-position) + "]"
+}
+
 func downloadToDownloadTableRow(download state.ListDownloadsWithQueueNameRow) table.Row {
-	return table.Row{download.Url, download.QueueName, download.State}
+	return table.Row{download.Url, download.QueueName, formatSize(download), download.State,
+		formatTime(download.CreatedAt), formatTime(download.CompletedAt), download.LastError.String}
+}
+
+func formatSize(download state.ListDownloadsWithQueueNameRow) string {
+	if !download.TotalSize.Valid {
+		return "unknown"
+	}
+	return FormatBytes(download.TotalSize.Int64)
+}
+
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.Local().Format(time.DateTime)
 }
 
 func defaultDownloadsListKeyMap() downloadsListKeyMap {
@@ -41,7 +41,7 @@ var queuesColumns = []table.Column{
 	{Title: "Bandwidth Limit (Bytes Per Second)", Width: 10},
 	{Title: "Download Directory", Width: 10},
 	{Title: "Maxiumum Concurrent Download", Width: 10},
-	{Title: "Start - End Time", Width: 10},
+	{Title: "Schedule", Width: 10},
 }
 
 type queueListKeyMap struct {
@@ -329,7 +329,7 @@ func NewQueuesList(ctx context.Context, queueManager queues.QueueManager) (types
 }
 
 func queueToQueueTableRow(queue state.Queue) table.Row {
-	var bandwidthLimit, startEndTime string
+	var bandwidthLimit, schedule string
 
 	if queue.MaxBandwidth.Valid {
 		bandwidthLimit = FormatBytesPerSecond(queue.MaxBandwidth.Int64)
@@ -338,11 +338,16 @@ func queueToQueueTableRow(queue state.Queue) table.Row {
 	}
 
 	if queue.ScheduleMode {
-		startEndTime = fmt.Sprintf("%s - %s", queue.StartDownload, queue.EndDownload)
+		schedule = queue.Schedule.String()
 	} else {
-		startEndTime = "No Schedule"
+		schedule = "No Schedule"
 	}
 
-	return table.Row{queue.Name, bandwidthLimit, queue.Directory,
-		strconv.Itoa(int(queue.MaxConcurrent)), startEndTime}
+	queueName := queue.Name
+	if queue.TlsInsecure {
+		queueName += " ⚠️ insecure TLS"
+	}
+
+	return table.Row{queueName, bandwidthLimit, queue.Directory,
+		strconv.Itoa(int(queue.MaxConcurrent)), schedule}
 }
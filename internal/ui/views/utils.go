@@ -24,27 +24,31 @@ func BPSStepHandler(_, value int64) int64 {
 	}
 }
 
-func FormatBytesPerSecond(bps int64) string {
+func FormatBytes(bytes int64) string {
 	const (
 		KB float64 = 1024
 		MB float64 = KB * 1024
 		GB float64 = MB * 1024
 	)
 
-	bytesPerSec := float64(bps)
+	size := float64(bytes)
 
 	switch {
-	case bytesPerSec >= GB:
-		return fmt.Sprintf("%.2f GB/s", bytesPerSec/GB)
-	case bytesPerSec >= MB:
-		return fmt.Sprintf("%.2f MB/s", bytesPerSec/MB)
-	case bytesPerSec >= KB:
-		return fmt.Sprintf("%.2f KB/s", bytesPerSec/KB)
+	case size >= GB:
+		return fmt.Sprintf("%.2f GB", size/GB)
+	case size >= MB:
+		return fmt.Sprintf("%.2f MB", size/MB)
+	case size >= KB:
+		return fmt.Sprintf("%.2f KB", size/KB)
 	default:
-		return fmt.Sprintf("%d B/s", bps)
+		return fmt.Sprintf("%d B", bytes)
 	}
 }
 
+func FormatBytesPerSecond(bps int64) string {
+	return FormatBytes(bps) + "/s"
+}
+
 func createErrorCmd(errMsg types.ErrorMsg) tea.Cmd {
 	return func() tea.Msg {
 		return errMsg
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/computer-technology-team/download-manager.git/cmd"
 	"github.com/computer-technology-team/download-manager.git/logging"
 )
@@ -16,6 +18,7 @@ func main() {
 
 	err = cmd.NewRootCmd().Execute()
 	if err != nil {
-		panic(err)
+		_ = onExit()
+		os.Exit(1)
 	}
 }